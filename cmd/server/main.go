@@ -12,12 +12,20 @@ import (
 	"time"
 
 	_ "github.com/middelmatigheid/subscriptions-api/docs"
+	"github.com/middelmatigheid/subscriptions-api/internal/auth"
+	"github.com/middelmatigheid/subscriptions-api/internal/cache"
 	"github.com/middelmatigheid/subscriptions-api/internal/config"
 	"github.com/middelmatigheid/subscriptions-api/internal/database"
 	"github.com/middelmatigheid/subscriptions-api/internal/handlers"
+	"github.com/middelmatigheid/subscriptions-api/internal/idempotency"
+	"github.com/middelmatigheid/subscriptions-api/internal/memory"
+	"github.com/middelmatigheid/subscriptions-api/internal/models"
+	"github.com/middelmatigheid/subscriptions-api/internal/ratelimit"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	_ "github.com/lib/pq"
+	"github.com/redis/go-redis/v9"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
@@ -58,8 +66,69 @@ func Logger(logger *slog.Logger) gin.HandlerFunc {
 	}
 }
 
+// Attaching id of the request and the label of the client from X-Actor header to the request context. The request id is being generated if the
+// client hasn't provided it, the actor is set by the authentication
+func RequestContext() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader("X-Request-ID")
+		if requestID == "" {
+			requestID = uuid.NewString()
+		}
+		c.Header("X-Request-ID", requestID)
+		ctx := models.WithRequestID(c.Request.Context(), requestID)
+		ctx = models.WithActorLabel(ctx, c.GetHeader("X-Actor"))
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// Marking the legacy routes as deprecated, the clients are being pointed to the successor routes
+func Deprecated(successor string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		c.Header("Link", "<"+successor+">; rel=\"successor-version\"")
+		c.Next()
+	}
+}
+
+// Purging the trash of each tenant and the expired idempotency keys periodically until the context is done. The purge acts as admin of the tenant
+func purge(ctx context.Context, service models.SubscriptionService, storage models.Storage, retention time.Duration, idempotencyTTL time.Duration,
+	interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if retention > 0 {
+				before := time.Now().Add(-retention)
+				tenants, err := storage.TrashTenants(ctx, before)
+				if err != nil {
+					logger.Error("Error while getting the tenants to purge the trash of", slog.String("error", err.Error()))
+				}
+				for _, tenant := range tenants {
+					tenantCtx := models.WithCredential(ctx, models.Credential{Name: "trash purge", Subject: "trash purge", TenantID: tenant, Role: models.RoleAdmin})
+					purged, err := service.Purge(tenantCtx, before)
+					if err != nil {
+						logger.Error("Error while purging the trash", slog.String("tenant", tenant), slog.String("error", err.Error()))
+					} else if purged > 0 {
+						logger.Info("The trash was purged", slog.String("tenant", tenant), slog.Int("purged", purged))
+					}
+				}
+			}
+			purged, err := storage.PurgeIdempotencyKeys(ctx, time.Now().Add(-idempotencyTTL))
+			if err != nil {
+				logger.Error("Error while purging the idempotency keys", slog.String("error", err.Error()))
+			} else if purged > 0 {
+				logger.Info("The expired idempotency keys were purged", slog.Int("purged", purged))
+			}
+		}
+	}
+}
+
 // Server graceful shutdown
-func gracefulShutdown(server *http.Server, db *database.Database, logger *slog.Logger) {
+func gracefulShutdown(server *http.Server, db models.Storage, logger *slog.Logger) {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
@@ -83,7 +152,10 @@ func gracefulShutdown(server *http.Server, db *database.Database, logger *slog.L
 // @version 1.0
 // @description It is just a simple API to manage subscriptions
 // @host localhost:8080
-// @BasePath /subscriptions/
+// @BasePath /
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
 func main() {
 	// Configuring logger
 	logDir := "/logs"
@@ -105,31 +177,150 @@ func main() {
 		return
 	}
 
-	// Connecting to the database
-	db, err := database.Connect(config, logger)
+	// Setting up the storage
+	var db models.Storage
+	switch config.Storage {
+	case "memory":
+		db = memory.NewMemory()
+		logger.Info("Using in-memory storage")
+	case "postgres":
+		db, err = database.Connect(config, logger)
+		if err != nil {
+			logger.Error("Error while connecting to the database", slog.String("error", err.Error()))
+			return
+		}
+	default:
+		logger.Error("Unknown storage", slog.String("storage", config.Storage))
+		return
+	}
+
+	// Connecting to redis once, the client is shared by the cache and the rate limiter
+	var redisClient *redis.Client
+	if config.Cache == "redis" || config.RateLimit == "redis" {
+		redisClient, err = cache.NewRedisClient(config)
+		if err != nil {
+			logger.Error("Error while connecting to redis", slog.String("error", err.Error()))
+			return
+		}
+		defer redisClient.Close()
+	}
+	subscriptionCache, err := cache.NewCache(config, redisClient)
 	if err != nil {
-		logger.Error("Error while connecting to the database", slog.String("error", err.Error()))
+		logger.Error("Error while creating the cache", slog.String("error", err.Error()))
 		return
 	}
 
 	// Setting up the handler
-	handler, err := handlers.NewHandler(config, db)
+	handler, err := handlers.NewHandler(config, db, subscriptionCache)
 	if err != nil {
 		logger.Error("Error while creating the handler", slog.String("error", err.Error()))
 		return
 	}
-	// Setting up the endpoints
+	// Purging deleted subscriptions after the retention period and the expired idempotency keys, the purge acts as admin
+	if config.TrashPurgeInterval > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		ctx = models.WithActor(ctx, "trash purge")
+		go purge(ctx, handler.Service, db, time.Duration(config.TrashRetention)*24*time.Hour, time.Duration(config.IdempotencyTTL)*time.Hour,
+			time.Duration(config.TrashPurgeInterval)*time.Minute, logger)
+	}
+
+	// Setting up the authentication
+	var authenticate gin.HandlerFunc
+	switch config.Auth {
+	case "api_key":
+		tokens, err := auth.NewTokens(config)
+		if err != nil {
+			logger.Error("Error while loading the token keys", slog.String("error", err.Error()))
+			return
+		}
+		authenticate = auth.Middleware(db, config.AdminAPIKey, tokens)
+		if config.AdminAPIKey == "" {
+			logger.Warn("Admin api key is not set, the api keys can't be issued until one is stored in the database")
+		}
+	case "none":
+		authenticate = auth.Disabled()
+		logger.Warn("Authentication is turned off")
+	default:
+		logger.Error("Unknown authentication", slog.String("auth", config.Auth))
+		return
+	}
+	read, write, admin := auth.Require(models.ScopeRead), auth.Require(models.ScopeWrite), auth.Require(models.ScopeAdmin)
+
+	// Setting up the rate limiting. Each ip is limited before the authentication, so the made up keys don't reach the storage. Each client has the
+	// bucket shared by all the routes, the heavy routes aggregating or changing many subscriptions have the stricter bucket of their own
+	limiter, err := ratelimit.NewLimiter(config, redisClient)
+	if err != nil {
+		logger.Error("Error while creating the rate limiter", slog.String("error", err.Error()))
+		return
+	}
+	limitIP := ratelimit.IPMiddleware(limiter, "ip", ratelimit.Limit{Rate: config.RateLimitIPRPM, Burst: config.RateLimitIPBurst})
+	limit := ratelimit.Middleware(limiter, "all", ratelimit.Limit{Rate: config.RateLimitRPM, Burst: config.RateLimitBurst})
+	heavy := func(route string) gin.HandlerFunc {
+		routeLimit := config.RateLimitRoutes[route]
+		return ratelimit.Middleware(limiter, route, ratelimit.Limit{Rate: routeLimit.RPM, Burst: routeLimit.Burst})
+	}
+
+	// Setting up the endpoints, the clients are limited by ip, authenticated and limited. The idempotency keys are reserved after the scope is
+	// checked and the route is limited, so the rejected requests aren't replayed. The ip of the client is taken from the forwarding headers only
+	// behind the trusted proxies
 	server := gin.Default()
-	server.Use(Logger(logger))
-	subscriptions := server.Group("/subscriptions")
-	subscriptions.POST("/create", handler.Create)
-	subscriptions.GET("/read", handler.Read)
-	subscriptions.PUT("/update", handler.Update)
-	subscriptions.PUT("/patch", handler.Patch)
-	subscriptions.DELETE("/delete", handler.Delete)
-	subscriptions.GET("/list", handler.List)
-	subscriptions.GET("/summary", handler.Summary)
-	subscriptions.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	if err = server.SetTrustedProxies(config.TrustedProxies); err != nil {
+		logger.Error("Invalid trusted proxies", slog.String("error", err.Error()))
+		return
+	}
+	server.Use(Logger(logger), RequestContext())
+	server.GET("/subscriptions/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	// The body of the request made with the idempotency key is read before the handler, so it is capped by the size of the imported file, the
+	// largest body the api accepts
+	idempotent := idempotency.Middleware(db, time.Duration(config.IdempotencyTTL)*time.Hour, int64(config.ImportMaxSize)<<20)
+
+	api := server.Group("/api/v1", limitIP, authenticate, limit)
+	resources := api.Group("/subscriptions")
+	resources.POST("", write, idempotent, handler.Create)
+	resources.POST("/bulk", write, heavy("bulk"), idempotent, handler.Bulk)
+	resources.POST("/import", write, heavy("import"), idempotent, handler.Import)
+	resources.GET("/export", read, heavy("export"), handler.Export)
+	resources.GET("", read, handler.List)
+	resources.GET("/summary", read, heavy("summary"), handler.Summary)
+	resources.GET("/timeseries", read, heavy("timeseries"), handler.Timeseries)
+	resources.GET("/trash", read, handler.Trash)
+	resources.DELETE("/trash", admin, idempotent, handler.Purge)
+	resources.GET("/:id", read, handler.ReadByID)
+	resources.PUT("/:id", write, idempotent, handler.UpdateByID)
+	resources.PATCH("/:id", write, idempotent, handler.PatchByID)
+	resources.DELETE("/:id", write, idempotent, handler.DeleteByID)
+	resources.POST("/:id/restore", write, idempotent, handler.Restore)
+	resources.GET("/:id/history", read, handler.History)
+	resources.GET("/:id/prices", read, handler.Prices)
+	resources.POST("/:id/prices", write, idempotent, handler.SchedulePrice)
+	services := api.Group("/services")
+	services.POST("", admin, idempotent, handler.CreateService)
+	services.GET("", read, handler.ListServices)
+	services.GET("/:id", read, handler.ReadService)
+	services.PUT("/:id", admin, idempotent, handler.UpdateService)
+	services.DELETE("/:id", admin, idempotent, handler.DeleteService)
+	keys := api.Group("/keys", admin)
+	keys.POST("", idempotent, handler.IssueAPIKey)
+	keys.GET("", handler.ListAPIKeys)
+	keys.DELETE("/:id", idempotent, handler.RevokeAPIKey)
+	api.GET("/rates", read, handler.Rates)
+	api.PUT("/rates", admin, idempotent, handler.SetRates)
+
+	// Legacy endpoints are kept as deprecated aliases
+	subscriptions := server.Group("/subscriptions", Deprecated("/api/v1/subscriptions"), limitIP, authenticate, limit)
+	subscriptions.POST("/create", write, idempotent, handler.Create)
+	subscriptions.GET("/read", read, handler.Read)
+	subscriptions.PUT("/update", write, idempotent, handler.Update)
+	subscriptions.PUT("/patch", write, idempotent, handler.Patch)
+	subscriptions.DELETE("/delete", write, idempotent, handler.Delete)
+	subscriptions.GET("/list", read, handler.List)
+	subscriptions.GET("/summary", read, heavy("summary"), handler.Summary)
+	subscriptions.GET("/timeseries", read, heavy("timeseries"), handler.Timeseries)
+	subscriptions.GET("/export", read, heavy("export"), handler.Export)
+	subscriptions.POST("/import", write, heavy("import"), idempotent, handler.Import)
+	subscriptions.GET("/rates", read, handler.Rates)
+	subscriptions.PUT("/rates", admin, idempotent, handler.SetRates)
 
 	// Starting up the server
 	go func() {
@@ -0,0 +1,186 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/middelmatigheid/subscriptions-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Prefix of the issued keys, the prefix and the first characters of the key are kept to tell the keys apart
+const (
+	keyPrefix       = "sk_"
+	keyPrefixLength = len(keyPrefix) + 8
+)
+
+// Time of the last use of the key is being stored at most once per interval, so each request doesn't write to the storage
+const touchInterval = time.Minute
+
+// GenerateKey returns new random api key and its prefix
+func GenerateKey() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", models.NewErrInternalServer(err)
+	}
+	key := keyPrefix + hex.EncodeToString(b)
+	return key, key[:keyPrefixLength], nil
+}
+
+// HashKey returns hash of the api key the key is being stored and looked up by
+func HashKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// RequestKey returns the api key from X-API-Key header or from the bearer token of Authorization header, empty key is returned if there is none
+func RequestKey(c *gin.Context) string {
+	if key := strings.TrimSpace(c.GetHeader("X-API-Key")); key != "" {
+		return key
+	}
+	scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// Getting the tenant the client acts within from X-Tenant-ID header, the default tenant is used if the header is not provided. False is returned if
+// the tenant is malformed
+func requestTenant(c *gin.Context) (string, bool) {
+	tenant := strings.TrimSpace(c.GetHeader("X-Tenant-ID"))
+	if tenant == "" {
+		return models.DefaultTenant, true
+	}
+	return tenant, models.ValidTenant(tenant)
+}
+
+// Getting the role of the api key. The keys aren't bound to the users, so the keys with admin scope act as admins, the keys allowed to change the
+// subscriptions act as editors and the read only keys act as auditors
+func keyRole(scopes []string) string {
+	credential := models.Credential{Scopes: scopes}
+	switch {
+	case credential.HasScope(models.ScopeAdmin):
+		return models.RoleAdmin
+	case credential.HasScope(models.ScopeWrite):
+		return models.RoleEditor
+	}
+	return models.RoleAuditor
+}
+
+// Rejecting the request with malformed tenant
+func invalidTenant(c *gin.Context) {
+	c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"msg": "Invalid tenant id", "error": models.ErrBadRequest.Error()})
+}
+
+// Rejecting the request of the client which is not authenticated
+func unauthorized(c *gin.Context, msg string) {
+	c.Header("WWW-Authenticate", `Bearer realm="subscriptions"`)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"msg": msg, "error": models.ErrUnauthorized.Error()})
+}
+
+// Attaching the credential to the request context. The authenticated client is always named the actor of the changes, X-Actor header is kept
+// only as the label of the client
+func authenticated(c *gin.Context, credential models.Credential, actor string) {
+	ctx := models.WithActor(models.WithCredential(c.Request.Context(), credential), actor)
+	c.Request = c.Request.WithContext(ctx)
+	c.Next()
+}
+
+// Middleware authenticates the client by the api key or by the JWT if the tokens are accepted. Missing, unknown, expired and revoked keys and
+// invalid tokens are rejected with 401. The admin key is accepted with admin scope, so the first keys can be issued, the admin key is not accepted
+// if it is empty. The client acts within the tenant of its key or token, the admin key acts within the tenant of X-Tenant-ID header
+func Middleware(storage models.AuthStorage, adminKey string, tokens *Tokens) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := RequestKey(c)
+		if key == "" {
+			unauthorized(c, "Missing credential")
+			return
+		}
+		if adminKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(adminKey)) == 1 {
+			tenant, ok := requestTenant(c)
+			if !ok {
+				invalidTenant(c)
+				return
+			}
+			credential := models.Credential{Name: "admin", Subject: "admin key", Scopes: []string{models.ScopeAdmin}, TenantID: tenant, Role: models.RoleAdmin}
+			authenticated(c, credential, "api key admin")
+			return
+		}
+
+		// The issued keys have no dots, so the key with dots is a token
+		if tokens != nil && strings.Count(key, ".") == 2 {
+			credential, err := tokens.Verify(key)
+			if err != nil {
+				unauthorized(c, err.Error())
+				return
+			}
+			authenticated(c, credential, "user "+credential.Name)
+			return
+		}
+
+		// Getting the key by its hash
+		ctx := c.Request.Context()
+		apiKey, err := storage.ReadAPIKey(ctx, HashKey(key))
+		now := time.Now()
+		switch {
+		case errors.Is(err, models.ErrNotFound):
+			unauthorized(c, "Invalid api key")
+			return
+		case err != nil:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"msg": "An error occured while getting api key from the database", "error": err.Error()})
+			return
+		case apiKey.RevokedAt.Valid:
+			unauthorized(c, "The api key was revoked")
+			return
+		case apiKey.ExpiresAt.Valid && !apiKey.ExpiresAt.Time.After(now):
+			unauthorized(c, "The api key has expired")
+			return
+		}
+
+		// Storing time of the last use
+		if !apiKey.LastUsedAt.Valid || now.Sub(apiKey.LastUsedAt.Time) >= touchInterval {
+			storage.TouchAPIKey(ctx, apiKey.ID, now)
+		}
+		credential := models.Credential{KeyID: apiKey.ID, Name: apiKey.Name, Subject: "key " + strconv.Itoa(apiKey.ID), Scopes: apiKey.Scopes, TenantID: apiKey.TenantID, Role: keyRole(apiKey.Scopes)}
+		authenticated(c, credential, "api key "+apiKey.Name)
+	}
+}
+
+// Disabled attaches the anonymous credential with admin scope and admin role to every request, it is used when the authentication is turned off. The client acts
+// within the tenant of X-Tenant-ID header
+func Disabled() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenant, ok := requestTenant(c)
+		if !ok {
+			invalidTenant(c)
+			return
+		}
+		credential := models.Credential{Name: "anonymous", Subject: "anonymous", Scopes: []string{models.ScopeAdmin}, TenantID: tenant, Role: models.RoleAdmin}
+		authenticated(c, credential, "anonymous")
+	}
+}
+
+// Require rejects the requests of the clients not allowed to act within the scope with 403
+func Require(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		credential, ok := models.CredentialFromContext(c.Request.Context())
+		if !ok {
+			unauthorized(c, "Missing credential")
+			return
+		}
+		if !credential.HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"msg": "The credential doesn't have " + scope + " scope", "error": models.ErrForbidden.Error()})
+			return
+		}
+		c.Next()
+	}
+}
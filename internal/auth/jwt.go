@@ -0,0 +1,234 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/middelmatigheid/subscriptions-api/internal/config"
+	"github.com/middelmatigheid/subscriptions-api/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Difference between the clocks of the issuer and the api tolerated while the time claims are being checked
+const clockSkew = 30 * time.Second
+
+// Scopes of the tokens without scope claim by the role of the token
+var defaultTokenScopes = map[string][]string{
+	models.RoleAdmin:   {models.ScopeAdmin},
+	models.RoleMember:  {models.ScopeRead, models.ScopeWrite},
+	models.RoleEditor:  {models.ScopeRead, models.ScopeWrite},
+	models.RoleAuditor: {models.ScopeRead},
+}
+
+// Tokens verifies the JWTs signed with HS256 by the secret or with RS256 by the keys of the local JWKS file. The issuer and the audience are
+// checked if they are configured
+type Tokens struct {
+	secret   []byte
+	keys     map[string]*rsa.PublicKey
+	issuer   string
+	audience string
+}
+
+// Header of the token
+type tokenHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+// Claims of the token. The audience can be either a string or an array of strings, the time claims are seconds since the epoch
+type tokenClaims struct {
+	Subject   string          `json:"sub"`
+	Issuer    string          `json:"iss"`
+	Audience  json.RawMessage `json:"aud"`
+	ExpiresAt *float64        `json:"exp"`
+	NotBefore *float64        `json:"nbf"`
+	Scope     *string         `json:"scope"`
+	TenantID  string          `json:"tenant_id"`
+	Role      string          `json:"role"`
+}
+
+// Key of the JWKS file, only RSA signing keys are used
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+}
+
+// NewTokens creates the verifier of the tokens. Nil verifier is returned if neither the secret nor the JWKS file is configured
+func NewTokens(config *config.Config) (*Tokens, error) {
+	if config.JWTSecret == "" && config.JWKSFile == "" {
+		return nil, nil
+	}
+	tokens := &Tokens{secret: []byte(config.JWTSecret), keys: make(map[string]*rsa.PublicKey), issuer: config.JWTIssuer, audience: config.JWTAudience}
+	if config.JWKSFile == "" {
+		return tokens, nil
+	}
+
+	// Loading the keys
+	b, err := os.ReadFile(config.JWKSFile)
+	if err != nil {
+		return nil, models.NewErrInternalServer(err)
+	}
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err = json.Unmarshal(b, &jwks); err != nil {
+		return nil, models.NewErrInternalServer(err)
+	}
+	for _, key := range jwks.Keys {
+		if key.KeyType != "RSA" || (key.Use != "" && key.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(key.N)
+		if err != nil {
+			return nil, models.NewErrInternalServer(errors.New("Invalid modulus of the key " + key.KeyID))
+		}
+		e, err := base64.RawURLEncoding.DecodeString(key.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, models.NewErrInternalServer(errors.New("Invalid exponent of the key " + key.KeyID))
+		}
+		tokens.keys[key.KeyID] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	if len(tokens.keys) == 0 {
+		return nil, models.NewErrInternalServer(errors.New("No RSA keys in " + config.JWKSFile))
+	}
+	return tokens, nil
+}
+
+// Getting the key verifying RS256 signature. The key id can be omitted if there is only one key
+func (t *Tokens) key(id string) (*rsa.PublicKey, bool) {
+	if id == "" && len(t.keys) == 1 {
+		for _, key := range t.keys {
+			return key, true
+		}
+	}
+	key, ok := t.keys[id]
+	return key, ok
+}
+
+// Verifying signature of the token
+func (t *Tokens) verifySignature(header tokenHeader, signed string, signature []byte) error {
+	switch header.Algorithm {
+	case "HS256":
+		if len(t.secret) == 0 {
+			return errors.New("HS256 tokens are not accepted")
+		}
+		mac := hmac.New(sha256.New, t.secret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return errors.New("Invalid token signature")
+		}
+	case "RS256":
+		key, ok := t.key(header.KeyID)
+		if !ok {
+			return errors.New("Unknown token key")
+		}
+		digest := sha256.Sum256([]byte(signed))
+		if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) != nil {
+			return errors.New("Invalid token signature")
+		}
+	default:
+		return errors.New("Unsupported token algorithm " + header.Algorithm)
+	}
+	return nil
+}
+
+// Checking if the audience claim contains the audience
+func hasAudience(claim json.RawMessage, audience string) bool {
+	var single string
+	if json.Unmarshal(claim, &single) == nil {
+		return single == audience
+	}
+	var multiple []string
+	return json.Unmarshal(claim, &multiple) == nil && slices.Contains(multiple, audience)
+}
+
+// Verify checks the token and returns the credential of its subject. The token should have the subject and the expiration time. The role is taken
+// from the role claim, the token without it acts as admin if it has admin scope and as member otherwise. The scopes are taken from the space
+// separated scope claim, the scopes of the role are granted if there is no such claim. The subject of the member's token should be the user uuid,
+// the credential is restricted to the user's subscriptions. The tenant is taken from tenant_id claim, the default tenant is used if there is no
+// such claim
+func (t *Tokens) Verify(token string) (models.Credential, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return models.Credential{}, errors.New("Malformed token")
+	}
+
+	// Verifying the signature
+	var header tokenHeader
+	b, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || json.Unmarshal(b, &header) != nil {
+		return models.Credential{}, errors.New("Malformed token header")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return models.Credential{}, errors.New("Malformed token signature")
+	}
+	if err = t.verifySignature(header, parts[0]+"."+parts[1], signature); err != nil {
+		return models.Credential{}, err
+	}
+
+	// Checking the claims
+	var claims tokenClaims
+	b, err = base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || json.Unmarshal(b, &claims) != nil {
+		return models.Credential{}, errors.New("Malformed token claims")
+	}
+	now := time.Now()
+	switch {
+	case claims.ExpiresAt == nil:
+		return models.Credential{}, errors.New("The token has no expiration time")
+	case now.After(time.Unix(int64(*claims.ExpiresAt), 0).Add(clockSkew)):
+		return models.Credential{}, errors.New("The token has expired")
+	case claims.NotBefore != nil && now.Add(clockSkew).Before(time.Unix(int64(*claims.NotBefore), 0)):
+		return models.Credential{}, errors.New("The token is not valid yet")
+	case t.issuer != "" && claims.Issuer != t.issuer:
+		return models.Credential{}, errors.New("Invalid token issuer")
+	case t.audience != "" && !hasAudience(claims.Audience, t.audience):
+		return models.Credential{}, errors.New("Invalid token audience")
+	case claims.Subject == "":
+		return models.Credential{}, errors.New("The token has no subject")
+	case claims.TenantID != "" && !models.ValidTenant(claims.TenantID):
+		return models.Credential{}, errors.New("Invalid token tenant")
+	}
+
+	// Getting the credential
+	credential := models.Credential{Name: claims.Subject, Subject: "token " + claims.Subject, TenantID: models.DefaultTenant, Role: claims.Role}
+	if claims.TenantID != "" {
+		credential.TenantID = claims.TenantID
+	}
+	if claims.Scope != nil {
+		credential.Scopes = strings.Fields(*claims.Scope)
+	}
+	if credential.Role == "" && credential.HasScope(models.ScopeAdmin) {
+		credential.Role = models.RoleAdmin
+	} else if credential.Role == "" {
+		credential.Role = models.RoleMember
+	}
+	scopes, ok := defaultTokenScopes[credential.Role]
+	if !ok {
+		return models.Credential{}, errors.New("Invalid token role")
+	}
+	if claims.Scope == nil {
+		credential.Scopes = slices.Clone(scopes)
+	}
+	if credential.Role == models.RoleMember {
+		if credential.UserUUID, err = uuid.Parse(claims.Subject); err != nil || credential.UserUUID == uuid.Nil {
+			return models.Credential{}, errors.New("The token's subject is not a user uuid")
+		}
+	}
+	return credential, nil
+}
@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/middelmatigheid/subscriptions-api/internal/config"
+	"github.com/middelmatigheid/subscriptions-api/internal/models"
+)
+
+// Uuid of the user the members' tokens are issued to
+const user = "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+
+// Encoding the part of the token
+func encode(t *testing.T, part map[string]any) string {
+	b, err := json.Marshal(part)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Signing the token with HS256 by the secret
+func signHS256(t *testing.T, secret string, header map[string]any, claims map[string]any) string {
+	signed := encode(t, header) + "." + encode(t, claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Signing the token with RS256 by the key
+func signRS256(t *testing.T, key *rsa.PrivateKey, claims map[string]any) string {
+	signed := encode(t, map[string]any{"alg": "RS256", "kid": "main"}) + "." + encode(t, claims)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("SignPKCS1v15() error = %v", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// Writing the JWKS file with the public key
+func writeJWKS(t *testing.T, key *rsa.PublicKey) string {
+	jwks := map[string]any{"keys": []map[string]any{{"kty": "RSA", "kid": "main", "use": "sig",
+		"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()), "e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())}}}
+	b, err := json.Marshal(jwks)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err = os.WriteFile(path, b, 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path
+}
+
+// Getting the claims of the member's token expiring in an hour merged with the claims
+func claims(overrides map[string]any) map[string]any {
+	claims := map[string]any{"sub": user, "exp": time.Now().Add(time.Hour).Unix()}
+	for name, value := range overrides {
+		if value == nil {
+			delete(claims, name)
+			continue
+		}
+		claims[name] = value
+	}
+	return claims
+}
+
+func TestVerify(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	newTokens := func(config *config.Config) *Tokens {
+		tokens, err := NewTokens(config)
+		if err != nil {
+			t.Fatalf("NewTokens() error = %v", err)
+		}
+		return tokens
+	}
+	hs := newTokens(&config.Config{JWTSecret: "secret"})
+	rs := newTokens(&config.Config{JWKSFile: writeJWKS(t, &key.PublicKey)})
+	restricted := newTokens(&config.Config{JWTSecret: "secret", JWTIssuer: "https://auth.example.com", JWTAudience: "subscriptions"})
+	now, hs256 := time.Now(), map[string]any{"alg": "HS256"}
+
+	tests := []struct {
+		name     string
+		tokens   *Tokens
+		token    string
+		wantErr  bool
+		wantRole string
+	}{
+		{"member", hs, signHS256(t, "secret", hs256, claims(nil)), false, models.RoleMember},
+		{"other secret", hs, signHS256(t, "other", hs256, claims(nil)), true, ""},
+		{"malformed", hs, "header.claims", true, ""},
+		{"none algorithm", hs, encode(t, map[string]any{"alg": "none"}) + "." + encode(t, claims(nil)) + ".", true, ""},
+		{"unknown algorithm", hs, signHS256(t, "secret", map[string]any{"alg": "HS512"}, claims(nil)), true, ""},
+		{"RS256 without keys", hs, signRS256(t, key, claims(nil)), true, ""},
+		{"RS256", rs, signRS256(t, key, claims(nil)), false, models.RoleMember},
+		{"HS256 with only keys", rs, signHS256(t, "", hs256, claims(nil)), true, ""},
+		{"without expiration", hs, signHS256(t, "secret", hs256, claims(map[string]any{"exp": nil})), true, ""},
+		{"expired", hs, signHS256(t, "secret", hs256, claims(map[string]any{"exp": now.Add(-time.Minute).Unix()})), true, ""},
+		{"expired within skew", hs, signHS256(t, "secret", hs256, claims(map[string]any{"exp": now.Add(-10 * time.Second).Unix()})), false, models.RoleMember},
+		{"not valid yet", hs, signHS256(t, "secret", hs256, claims(map[string]any{"nbf": now.Add(time.Minute).Unix()})), true, ""},
+		{"not valid yet within skew", hs, signHS256(t, "secret", hs256, claims(map[string]any{"nbf": now.Add(10 * time.Second).Unix()})), false, models.RoleMember},
+		{"issuer and audience", restricted, signHS256(t, "secret", hs256, claims(map[string]any{"iss": "https://auth.example.com", "aud": "subscriptions"})), false,
+			models.RoleMember},
+		{"audience among others", restricted, signHS256(t, "secret", hs256, claims(map[string]any{"iss": "https://auth.example.com",
+			"aud": []string{"billing", "subscriptions"}})), false, models.RoleMember},
+		{"other issuer", restricted, signHS256(t, "secret", hs256, claims(map[string]any{"iss": "https://other.example.com", "aud": "subscriptions"})), true, ""},
+		{"other audience", restricted, signHS256(t, "secret", hs256, claims(map[string]any{"iss": "https://auth.example.com", "aud": []string{"billing"}})), true, ""},
+		{"without audience", restricted, signHS256(t, "secret", hs256, claims(map[string]any{"iss": "https://auth.example.com"})), true, ""},
+		{"member without user", hs, signHS256(t, "secret", hs256, claims(map[string]any{"sub": "alice"})), true, ""},
+		{"admin by scope", hs, signHS256(t, "secret", hs256, claims(map[string]any{"sub": "alice", "scope": "read admin"})), false, models.RoleAdmin},
+		{"auditor", hs, signHS256(t, "secret", hs256, claims(map[string]any{"sub": "alice", "role": models.RoleAuditor})), false, models.RoleAuditor},
+		{"unknown role", hs, signHS256(t, "secret", hs256, claims(map[string]any{"role": "owner"})), true, ""},
+		{"invalid tenant", hs, signHS256(t, "secret", hs256, claims(map[string]any{"tenant_id": "sales team"})), true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			credential, err := tt.tokens.Verify(tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify() error = %v, want error %t", err, tt.wantErr)
+			}
+			if err == nil && credential.Role != tt.wantRole {
+				t.Errorf("Verify() role = %q, want %q", credential.Role, tt.wantRole)
+			}
+		})
+	}
+}
+
+func TestVerifyCredential(t *testing.T) {
+	tokens, err := NewTokens(&config.Config{JWTSecret: "secret"})
+	if err != nil {
+		t.Fatalf("NewTokens() error = %v", err)
+	}
+	token := signHS256(t, "secret", map[string]any{"alg": "HS256"}, claims(map[string]any{"tenant_id": "finance"}))
+	credential, err := tokens.Verify(token)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	// The member is restricted to the user's subscriptions within the tenant of the token and granted the scopes of the role
+	if credential.UserUUID.String() != user || credential.TenantID != "finance" || credential.Subject != "token "+user {
+		t.Errorf("Verify() = %+v, want the member of finance restricted to the user", credential)
+	}
+	if !credential.HasScope(models.ScopeWrite) || credential.HasScope(models.ScopeAdmin) {
+		t.Errorf("Verify() scopes = %v, want read and write", credential.Scopes)
+	}
+}
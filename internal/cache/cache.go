@@ -2,7 +2,7 @@ package cache
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -13,87 +13,53 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
-type Cache struct {
-	client *redis.Client
-	ttl    time.Duration
-}
-
-// Creates cache
-func NewCache(config *config.Config) (*Cache, error) {
-	// Getting the redis client
-	client := redis.NewClient(&redis.Options{
-		Addr:     config.RedisHost + ":" + config.RedisPort,
-		Password: config.RedisPassword,
-		DB:       config.RedisDB,
-	})
-
-	// Checking the connection
-	ctx := context.Background()
-	if err := client.Ping(ctx).Err(); err != nil {
-		return nil, err
-	}
+// Cache stores recently used subscriptions, the subscriptions are being kept apart by the tenant of the context. A missing subscription is
+// returned as nil without an error
+type Cache interface {
+	Close() error
 
-	return &Cache{
-		client: client,
-		ttl:    time.Duration(config.RedisTTL) * time.Minute,
-	}, nil
+	SetSubscription(context.Context, models.Subscription) error
+	GetSubscription(context.Context, models.SubscriptionIdentifier) (*models.Subscription, error)
+	DeleteSubscription(context.Context, models.SubscriptionIdentifier) error
 }
 
-func (c *Cache) Close() error {
-	return c.client.Close()
+// Creates cache of the configured kind, the redis cache uses the client shared with the rate limiter. Nil cache is returned if caching is disabled
+func NewCache(config *config.Config, client *redis.Client) (Cache, error) {
+	switch config.Cache {
+	case "redis":
+		if client == nil {
+			return nil, models.NewErrInternalServer(errors.New("Redis client is not connected"))
+		}
+		return NewRedis(config, client), nil
+	case "lru":
+		return NewLRU(config.CacheSize, time.Duration(config.CacheTTL)*time.Minute), nil
+	case "none":
+		return nil, nil
+	default:
+		return nil, models.NewErrInternalServer(errors.New("Unknown cache " + config.Cache))
+	}
 }
 
-// Get key to the subscription by its id
-func (c *Cache) subID(id int) string {
-	return fmt.Sprintf("sub:%d", id)
+// Get key to the subscription of the tenant by its id
+func subID(ctx context.Context, id int) string {
+	return fmt.Sprintf("sub:%s:%d", models.TenantFromContext(ctx), id)
 }
 
-// Get key to the subscription by combination of user uuid and service name
-func (c *Cache) subUserAndService(userUUID uuid.UUID, serviceName string) string {
-	return fmt.Sprintf("sub:%s:%s", userUUID, serviceName)
+// Get key to the subscription of the tenant by combination of user uuid and service name
+func subUserAndService(ctx context.Context, userUUID uuid.UUID, serviceName string) string {
+	return fmt.Sprintf("sub:%s:%s:%s", models.TenantFromContext(ctx), userUUID, serviceName)
 }
 
-// Cache in subscription
-func (c *Cache) SetSubscription(ctx context.Context, subscription models.Subscription) error {
-	data, err := json.Marshal(subscription)
-	if err != nil {
-		return err
-	}
-	return c.client.Set(ctx, c.subID(subscription.ID), data, c.ttl).Err()
+// Get keys the subscription of the tenant is being cached under, the subscription is looked up both by its id and by combination of user uuid and
+// service name
+func subKeys(ctx context.Context, subscription models.Subscription) []string {
+	return []string{subID(ctx, subscription.ID), subUserAndService(ctx, subscription.UserUUID, subscription.ServiceName)}
 }
 
-// Get the subscription from the cache
-func (c *Cache) GetSubscription(ctx context.Context, identifier models.SubscriptionIdentifier) (*models.Subscription, error) {
-	var data []byte
-	var err error
+// Get key to the subscription of the tenant specified by the identifier
+func subIdentifier(ctx context.Context, identifier models.SubscriptionIdentifier) string {
 	if identifier.ID > 0 {
-		data, err = c.client.Get(ctx, c.subID(identifier.ID)).Bytes()
-		if err == redis.Nil {
-			return nil, nil
-		}
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		data, err = c.client.Get(ctx, c.subUserAndService(identifier.UserUUID, identifier.ServiceName)).Bytes()
-		if err == redis.Nil {
-			return nil, nil
-		}
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	var subscription models.Subscription
-	err = json.Unmarshal(data, &subscription)
-	return &subscription, err
-}
-
-// Delete invalid subscription from the cache
-func (c *Cache) DeleteSubscription(ctx context.Context, identifier models.SubscriptionIdentifier) error {
-	err := c.client.Del(ctx, c.subID(identifier.ID)).Err()
-	if err != nil {
-		return err
+		return subID(ctx, identifier.ID)
 	}
-	return c.client.Del(ctx, c.subUserAndService(identifier.UserUUID, identifier.ServiceName)).Err()
+	return subUserAndService(ctx, identifier.UserUUID, identifier.ServiceName)
 }
@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/middelmatigheid/subscriptions-api/internal/models"
+)
+
+// LRU is an in-process cache keeping at most size recently used subscriptions, each of them expires after ttl. The subscription is kept in one
+// element reachable by all of its keys
+type LRU struct {
+	mu    sync.Mutex
+	size  int
+	ttl   time.Duration
+	order *list.List
+	items map[string]*list.Element
+}
+
+type lruItem struct {
+	keys         []string
+	subscription models.Subscription
+	expiresAt    time.Time
+}
+
+// Creates in-process cache
+func NewLRU(size int, ttl time.Duration) *LRU {
+	return &LRU{
+		size:  size,
+		ttl:   ttl,
+		order: list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+func (c *LRU) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	c.items = make(map[string]*list.Element)
+	return nil
+}
+
+// Cache in subscription
+func (c *LRU) SetSubscription(ctx context.Context, subscription models.Subscription) error {
+	if c.size <= 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Dropping the previous state of the subscription, its user uuid or service name could have been changed
+	keys := subKeys(ctx, subscription)
+	for _, key := range keys {
+		if element, ok := c.items[key]; ok {
+			c.remove(element)
+		}
+	}
+	element := c.order.PushFront(lruItem{keys: keys, subscription: subscription, expiresAt: time.Now().Add(c.ttl)})
+	for _, key := range keys {
+		c.items[key] = element
+	}
+
+	// Evicting the least recently used subscriptions
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+	return nil
+}
+
+// Get the subscription from the cache
+func (c *LRU) GetSubscription(ctx context.Context, identifier models.SubscriptionIdentifier) (*models.Subscription, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.items[subIdentifier(ctx, identifier)]
+	if !ok {
+		return nil, nil
+	}
+	item := element.Value.(lruItem)
+	if time.Now().After(item.expiresAt) {
+		c.remove(element)
+		return nil, nil
+	}
+	c.order.MoveToFront(element)
+
+	subscription := item.subscription
+	return &subscription, nil
+}
+
+// Delete invalid subscription from the cache, the subscription is dropped under all of its keys
+func (c *LRU) DeleteSubscription(ctx context.Context, identifier models.SubscriptionIdentifier) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range []string{subID(ctx, identifier.ID), subUserAndService(ctx, identifier.UserUUID, identifier.ServiceName)} {
+		if element, ok := c.items[key]; ok {
+			c.remove(element)
+		}
+	}
+	return nil
+}
+
+// Removing the element from the cache under all of its keys. The lock should be held by the caller
+func (c *LRU) remove(element *list.Element) {
+	c.order.Remove(element)
+	for _, key := range element.Value.(lruItem).keys {
+		if c.items[key] == element {
+			delete(c.items, key)
+		}
+	}
+}
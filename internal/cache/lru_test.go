@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/middelmatigheid/subscriptions-api/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestLRUKeys(t *testing.T) {
+	c, ctx := NewLRU(10, time.Minute), context.Background()
+	subscription := models.Subscription{ID: 1, ServiceName: "Yandex Plus", UserUUID: uuid.New(), Price: 400}
+	byID := models.SubscriptionIdentifier{ID: 1}
+	byUserAndService := models.SubscriptionIdentifier{UserUUID: subscription.UserUUID, ServiceName: subscription.ServiceName}
+	c.SetSubscription(ctx, subscription)
+
+	for _, identifier := range []models.SubscriptionIdentifier{byID, byUserAndService} {
+		if got, _ := c.GetSubscription(ctx, identifier); got == nil || got.ID != 1 {
+			t.Errorf("GetSubscription(%+v) = %v, want the subscription", identifier, got)
+		}
+	}
+
+	// Renamed subscription isn't reachable by the previous name
+	renamed := subscription
+	renamed.ServiceName = "Kinopoisk"
+	c.SetSubscription(ctx, renamed)
+	if got, _ := c.GetSubscription(ctx, byUserAndService); got != nil {
+		t.Errorf("GetSubscription() by previous name = %v, want nil", got)
+	}
+
+	// Deleting by id drops the subscription under all of its keys
+	c.DeleteSubscription(ctx, byID)
+	renamedIdentifier := models.SubscriptionIdentifier{UserUUID: renamed.UserUUID, ServiceName: renamed.ServiceName}
+	for _, identifier := range []models.SubscriptionIdentifier{byID, renamedIdentifier} {
+		if got, _ := c.GetSubscription(ctx, identifier); got != nil {
+			t.Errorf("GetSubscription(%+v) after delete = %v, want nil", identifier, got)
+		}
+	}
+	if len(c.items) != 0 || c.order.Len() != 0 {
+		t.Errorf("cache keeps %d keys and %d elements after delete", len(c.items), c.order.Len())
+	}
+}
+
+func TestLRUEviction(t *testing.T) {
+	c, ctx := NewLRU(2, time.Minute), context.Background()
+	for id := 1; id <= 3; id++ {
+		c.SetSubscription(ctx, models.Subscription{ID: id, ServiceName: "Yandex Plus", UserUUID: uuid.New()})
+	}
+	if got, _ := c.GetSubscription(ctx, models.SubscriptionIdentifier{ID: 1}); got != nil {
+		t.Errorf("GetSubscription() of evicted subscription = %v, want nil", got)
+	}
+	if len(c.items) != 4 {
+		t.Errorf("cache keeps %d keys, want 4", len(c.items))
+	}
+}
+
+func TestLRUTenants(t *testing.T) {
+	c := NewLRU(10, time.Minute)
+	finance := models.WithCredential(context.Background(), models.Credential{TenantID: "finance"})
+	sales := models.WithCredential(context.Background(), models.Credential{TenantID: "sales"})
+	c.SetSubscription(finance, models.Subscription{ID: 1, ServiceName: "Yandex Plus", UserUUID: uuid.New()})
+	if got, _ := c.GetSubscription(sales, models.SubscriptionIdentifier{ID: 1}); got != nil {
+		t.Errorf("GetSubscription() of other tenant = %v, want nil", got)
+	}
+}
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/middelmatigheid/subscriptions-api/internal/config"
+	"github.com/middelmatigheid/subscriptions-api/internal/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis is a cache stored in redis, it can be shared between several instances of the api
+type Redis struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// Creates redis cache
+func NewRedis(config *config.Config, client *redis.Client) *Redis {
+	return &Redis{
+		client: client,
+		ttl:    time.Duration(config.CacheTTL) * time.Minute,
+	}
+}
+
+// NewRedisClient connects to the configured redis, the client is shared by the cache and the rate limiter
+func NewRedisClient(config *config.Config) (*redis.Client, error) {
+	// Getting the redis client
+	client := redis.NewClient(&redis.Options{
+		Addr:     config.RedisHost + ":" + config.RedisPort,
+		Password: config.RedisPassword,
+		DB:       config.RedisDB,
+	})
+
+	// Checking the connection
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, models.NewErrInternalServer(err)
+	}
+	return client, nil
+}
+
+func (c *Redis) Close() error {
+	return c.client.Close()
+}
+
+// Cache in subscription under its id and combination of user uuid and service name
+func (c *Redis) SetSubscription(ctx context.Context, subscription models.Subscription) error {
+	data, err := json.Marshal(subscription)
+	if err != nil {
+		return err
+	}
+	pipe := c.client.TxPipeline()
+	for _, key := range subKeys(ctx, subscription) {
+		pipe.Set(ctx, key, data, c.ttl)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// Get the subscription from the cache
+func (c *Redis) GetSubscription(ctx context.Context, identifier models.SubscriptionIdentifier) (*models.Subscription, error) {
+	data, err := c.client.Get(ctx, subIdentifier(ctx, identifier)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var subscription models.Subscription
+	err = json.Unmarshal(data, &subscription)
+	return &subscription, err
+}
+
+// Delete invalid subscription from the cache. The cached subscription is dropped under all of its keys, so the subscription specified by id
+// isn't left cached under its user uuid and service name
+func (c *Redis) DeleteSubscription(ctx context.Context, identifier models.SubscriptionIdentifier) error {
+	keys := []string{subID(ctx, identifier.ID), subUserAndService(ctx, identifier.UserUUID, identifier.ServiceName)}
+	if cached, err := c.GetSubscription(ctx, identifier); err == nil && cached != nil {
+		keys = append(keys, subKeys(ctx, *cached)...)
+	}
+	return c.client.Del(ctx, keys...).Err()
+}
@@ -3,42 +3,177 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 	"github.com/middelmatigheid/subscriptions-api/internal/models"
 )
 
 type Config struct {
-	Port       string
+	Port    string
+	Storage string
+
 	DBUser     string
 	DBPassword string
 	DBName     string
 	DBHost     string
 	DBPort     string
 
+	Cache     string
+	CacheSize int
+	CacheTTL  int
+
 	RedisHost     string
 	RedisPort     string
 	RedisPassword string
 	RedisDB       int
-	RedisTTL      int
+
+	RatesBase string
+	RatesFile string
+	RatesURL  string
+
+	TrashRetention     int
+	TrashPurgeInterval int
+
+	IdempotencyTTL int
+
+	ImportMaxSize int
+
+	Auth        string
+	AdminAPIKey string
+	JWTSecret   string
+	JWKSFile    string
+	JWTIssuer   string
+	JWTAudience string
+
+	RateLimit           string
+	RateLimitRPM        int
+	RateLimitBurst      int
+	RateLimitHeavyRPM   int
+	RateLimitHeavyBurst int
+	RateLimitIPRPM      int
+	RateLimitIPBurst    int
+	RateLimitRoutes     map[string]RouteLimit
+
+	TrustedProxies []string
+}
+
+// Limit of the requests to the heavy route per minute and its burst
+type RouteLimit struct {
+	RPM   int
+	Burst int
 }
 
+// Heavy routes which limits can be configured one by one, the heavy limits are used for the routes without their own
+var HeavyRoutes = []string{"summary", "timeseries", "export", "import", "bulk"}
+
 func GetConfig() (*Config, error) {
 	err := godotenv.Load()
 	if err != nil {
 		return nil, models.NewErrInternalServer(err)
 	}
 
-	redisDB, err := strconv.Atoi(os.Getenv("REDIS_DB"))
+	cacheSize, err := getInt("CACHE_SIZE", 1000)
+	if err != nil {
+		return nil, models.NewErrInternalServer(err)
+	}
+	// REDIS_TTL is the deprecated name of CACHE_TTL, it is still read so the existing deployments keep their ttl
+	redisTTL, err := getInt("REDIS_TTL", 10)
+	if err != nil {
+		return nil, models.NewErrInternalServer(err)
+	}
+	cacheTTL, err := getInt("CACHE_TTL", redisTTL)
+	if err != nil {
+		return nil, models.NewErrInternalServer(err)
+	}
+	redisDB, err := getInt("REDIS_DB", 0)
+	if err != nil {
+		return nil, models.NewErrInternalServer(err)
+	}
+	trashRetention, err := getInt("TRASH_RETENTION", 30)
+	if err != nil {
+		return nil, models.NewErrInternalServer(err)
+	}
+	trashPurgeInterval, err := getInt("TRASH_PURGE_INTERVAL", 60)
+	if err != nil {
+		return nil, models.NewErrInternalServer(err)
+	}
+	idempotencyTTL, err := getInt("IDEMPOTENCY_TTL", 24)
+	if err != nil {
+		return nil, models.NewErrInternalServer(err)
+	}
+	importMaxSize, err := getInt("IMPORT_MAX_SIZE", 10)
+	if err != nil {
+		return nil, models.NewErrInternalServer(err)
+	}
+	rateLimitRPM, err := getInt("RATE_LIMIT_RPM", 600)
+	if err != nil {
+		return nil, models.NewErrInternalServer(err)
+	}
+	rateLimitBurst, err := getInt("RATE_LIMIT_BURST", 100)
+	if err != nil {
+		return nil, models.NewErrInternalServer(err)
+	}
+	rateLimitHeavyRPM, err := getInt("RATE_LIMIT_HEAVY_RPM", 30)
 	if err != nil {
 		return nil, models.NewErrInternalServer(err)
 	}
-	redisTTL, err := strconv.Atoi(os.Getenv("REDIS_TTL"))
+	rateLimitHeavyBurst, err := getInt("RATE_LIMIT_HEAVY_BURST", 5)
 	if err != nil {
 		return nil, models.NewErrInternalServer(err)
 	}
+	rateLimitIPRPM, err := getInt("RATE_LIMIT_IP_RPM", 1200)
+	if err != nil {
+		return nil, models.NewErrInternalServer(err)
+	}
+	rateLimitIPBurst, err := getInt("RATE_LIMIT_IP_BURST", 200)
+	if err != nil {
+		return nil, models.NewErrInternalServer(err)
+	}
+	rateLimitRoutes := make(map[string]RouteLimit, len(HeavyRoutes))
+	for _, route := range HeavyRoutes {
+		var limit RouteLimit
+		if limit.RPM, err = getInt("RATE_LIMIT_"+strings.ToUpper(route)+"_RPM", rateLimitHeavyRPM); err != nil {
+			return nil, models.NewErrInternalServer(err)
+		}
+		if limit.Burst, err = getInt("RATE_LIMIT_"+strings.ToUpper(route)+"_BURST", rateLimitHeavyBurst); err != nil {
+			return nil, models.NewErrInternalServer(err)
+		}
+		rateLimitRoutes[route] = limit
+	}
+	var trustedProxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			trustedProxies = append(trustedProxies, proxy)
+		}
+	}
+
+	return &Config{Port: os.Getenv("PORT"), Storage: getString("STORAGE", "postgres"), DBUser: os.Getenv("DB_USER"), DBPassword: os.Getenv("DB_PASSWORD"),
+		DBName: os.Getenv("DB_NAME"), DBHost: os.Getenv("DB_HOST"), DBPort: os.Getenv("DB_PORT"), Cache: getString("CACHE", "redis"), CacheSize: cacheSize,
+		CacheTTL: cacheTTL, RedisHost: os.Getenv("REDIS_HOST"), RedisPort: os.Getenv("REDIS_PORT"), RedisPassword: os.Getenv("REDIS_PASSWORD"), RedisDB: redisDB,
+		RatesBase: getString("RATES_BASE", "RUB"), RatesFile: os.Getenv("RATES_FILE"), RatesURL: os.Getenv("RATES_URL"),
+		TrashRetention: trashRetention, TrashPurgeInterval: trashPurgeInterval, IdempotencyTTL: idempotencyTTL, ImportMaxSize: importMaxSize,
+		Auth: getString("AUTH", "api_key"), AdminAPIKey: os.Getenv("ADMIN_API_KEY"),
+		JWTSecret: os.Getenv("JWT_SECRET"), JWKSFile: os.Getenv("JWKS_FILE"), JWTIssuer: os.Getenv("JWT_ISSUER"), JWTAudience: os.Getenv("JWT_AUDIENCE"),
+		RateLimit: getString("RATE_LIMIT", "memory"), RateLimitRPM: rateLimitRPM, RateLimitBurst: rateLimitBurst, RateLimitHeavyRPM: rateLimitHeavyRPM,
+		RateLimitHeavyBurst: rateLimitHeavyBurst, RateLimitIPRPM: rateLimitIPRPM, RateLimitIPBurst: rateLimitIPBurst, RateLimitRoutes: rateLimitRoutes,
+		TrustedProxies: trustedProxies}, nil
+}
 
-	return &Config{Port: os.Getenv("PORT"), DBUser: os.Getenv("DB_USER"), DBPassword: os.Getenv("DB_PASSWORD"), DBName: os.Getenv("DB_NAME"),
-		DBHost: os.Getenv("DB_HOST"), DBPort: os.Getenv("DB_PORT"), RedisHost: os.Getenv("REDIS_HOST"), RedisPort: os.Getenv("REDIS_PORT"),
-		RedisPassword: os.Getenv("REDIS_PASSWORD"), RedisDB: redisDB, RedisTTL: redisTTL}, nil
+// Getting string variable, fallback is used if the variable is not set
+func getString(key string, fallback string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// Getting integer variable, fallback is used if the variable is not set
+func getInt(key string, fallback int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	return strconv.Atoi(value)
 }
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/middelmatigheid/subscriptions-api/internal/models"
+)
+
+// Bulk executes the operations either in one transaction or each in its own transaction. If an operation fails in one transaction, the other ones
+// are aborted
+func (db *Database) Bulk(ctx context.Context, operations []models.BulkOperation, allOrNothing bool) ([]models.BulkResult, error) {
+	results := make([]models.BulkResult, len(operations))
+	for i, operation := range operations {
+		results[i] = models.BulkResult{Index: i, Op: operation.Op}
+	}
+
+	// Executing the operations independently
+	if !allOrNothing {
+		for i, operation := range operations {
+			results[i].Err = db.transaction(ctx, func(tx *sql.Tx) error {
+				var err error
+				results[i].ID, err = apply(ctx, tx, operation)
+				return err
+			})
+		}
+		return results, nil
+	}
+
+	// Executing the operations in one transaction until the first failure
+	failed := -1
+	err := db.transaction(ctx, func(tx *sql.Tx) error {
+		for i, operation := range operations {
+			var err error
+			results[i].ID, err = apply(ctx, tx, operation)
+			if err != nil {
+				failed = i
+				return err
+			}
+		}
+		return nil
+	})
+	if failed < 0 && err != nil {
+		return nil, err
+	}
+	for i := range results {
+		if i == failed {
+			results[i].Err = err
+		} else if failed >= 0 {
+			results[i].Err = models.NewErrAborted()
+			if results[i].Op == models.BulkCreate {
+				results[i].ID = 0
+			}
+		}
+	}
+	return results, nil
+}
+
+// Applying the operation and returning id of the subscription
+func apply(ctx context.Context, q querier, operation models.BulkOperation) (int, error) {
+	switch operation.Op {
+	case models.BulkCreate:
+		res, err := create(ctx, q, operation.Subscription)
+		return res.ID, err
+	case models.BulkUpdate:
+		return operation.Subscription.ID, update(ctx, q, operation.Subscription)
+	case models.BulkDelete:
+		return operation.ID, remove(ctx, q, models.SubscriptionIdentifier{ID: operation.ID, Version: operation.Version})
+	}
+	return 0, models.NewErrBadRequest(errors.New("Unknown operation " + operation.Op))
+}
@@ -6,10 +6,16 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/middelmatigheid/subscriptions-api/internal/config"
 	"github.com/middelmatigheid/subscriptions-api/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type Database struct {
@@ -17,6 +23,37 @@ type Database struct {
 	logger *slog.Logger
 }
 
+// Tags of the subscription ordered by name
+const subscriptionTags = `ARRAY(SELECT tags.name FROM subscription_tags JOIN tags ON tags.id = subscription_tags.tag_id
+	WHERE subscription_tags.subscription_id = subscriptions.id ORDER BY tags.name)`
+
+// Columns of the subscriptions table in order they are being scanned
+const subscriptionColumns = `id, service_name, price, currency, billing_unit, billing_interval, user_uuid, start_date, end_date, version, created_at, updated_at, deleted_at,
+	service_id, category, tenant_id, ` + subscriptionTags
+
+// Time between billing dates of the subscription
+const billingStep = `CASE billing_unit
+		WHEN 'week' THEN make_interval(weeks => billing_interval)
+		WHEN 'quarter' THEN make_interval(months => 3 * billing_interval)
+		WHEN 'year' THEN make_interval(years => billing_interval)
+		ELSE make_interval(months => billing_interval) END`
+
+// Scanning the row into subscription type
+func scanSubscription(row interface{ Scan(...any) error }) (models.Subscription, error) {
+	var subscription models.Subscription
+	var serviceID sql.NullInt64
+	err := row.Scan(&subscription.ID, &subscription.ServiceName, &subscription.Price, &subscription.Currency, &subscription.BillingUnit, &subscription.BillingInterval,
+		&subscription.UserUUID, &subscription.StartDate, &subscription.EndDate, &subscription.Version, &subscription.CreatedAt, &subscription.UpdatedAt, &subscription.DeletedAt,
+		&serviceID, &subscription.Category, &subscription.TenantID, pq.Array(&subscription.Tags))
+	subscription.ServiceID = int(serviceID.Int64)
+	return subscription, err
+}
+
+// Getting id of the service the subscription references, null if the service isn't in the catalog
+func serviceID(subscription models.Subscription) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(subscription.ServiceID), Valid: subscription.ServiceID > 0}
+}
+
 // Connect establishes connection with PostgreSQL database
 func Connect(config *config.Config, logger *slog.Logger) (*Database, error) {
 	// Connecting to the database
@@ -32,6 +69,29 @@ func Connect(config *config.Config, logger *slog.Logger) (*Database, error) {
 	return &Database{database, logger}, nil
 }
 
+// Querier runs the queries either on the database or within a transaction
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Running the function within a transaction. The transaction is being committed if the function succeeds and rolled back otherwise
+func (db *Database) transaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.NewErrInternalServer(err)
+	}
+	if err = fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return models.NewErrInternalServer(err)
+	}
+	return nil
+}
+
 // Close terminates the connection with the database
 func (db *Database) Close() error {
 	err := db.DB.Close()
@@ -43,8 +103,131 @@ func (db *Database) Close() error {
 
 // Create inserts new subscription into the database and returns its id, if the insertion was successful, or returs id of conflicting subscription
 func (db *Database) Create(ctx context.Context, subscription models.Subscription) (models.IDResponse, error) {
+	var res models.IDResponse
+	err := db.transaction(ctx, func(tx *sql.Tx) error {
+		var err error
+		res, err = create(ctx, tx, subscription)
+		return err
+	})
+	return res, err
+}
+
+// Read returns the subscription's info stored in the database. The subscription is being specified by its id or combination of user uuid and service name,
+// deleted subscriptions are not being read
+func (db *Database) Read(ctx context.Context, identifier models.SubscriptionIdentifier) (models.Subscription, error) {
+	return read(ctx, db, identifier)
+}
+
+// Update updates subscription's info in the database. The subscription is being specified by its id, the subscription is updated only if its version matches
+func (db *Database) Update(ctx context.Context, subscription models.Subscription) error {
+	return db.transaction(ctx, func(tx *sql.Tx) error {
+		return update(ctx, tx, subscription)
+	})
+}
+
+// Delete moves a subscription to the trash. The subscriptions can be specified by its id or combination of user uuid and service name,
+// the subscription is deleted only if its version matches
+func (db *Database) Delete(ctx context.Context, identifier models.SubscriptionIdentifier) error {
+	return db.transaction(ctx, func(tx *sql.Tx) error {
+		return remove(ctx, tx, identifier)
+	})
+}
+
+// Restore moves the subscription specified by its id back from the trash, if there is no other subscription of the same user and service.
+// The subscription is restored only if its version matches and it belongs to the user if the user uuid is provided
+func (db *Database) Restore(ctx context.Context, identifier models.SubscriptionIdentifier) error {
+	return db.transaction(ctx, func(tx *sql.Tx) error {
+		// Checking if the subscription is in the trash
+		before, err := lock(ctx, tx, identifier.ID, true)
+		if err != nil {
+			return err
+		}
+		if identifier.UserUUID != uuid.Nil && before.UserUUID != identifier.UserUUID {
+			return models.NewErrNotFound()
+		}
+
+		// Checking if the same subscription exists in the database
+		_, err = read(ctx, tx, models.SubscriptionIdentifier{UserUUID: before.UserUUID, ServiceName: before.ServiceName})
+		if err != nil && !errors.Is(err, models.ErrNotFound) {
+			return err
+		} else if err == nil {
+			return models.NewErrConflict()
+		}
+		if identifier.Version != 0 && identifier.Version != before.Version {
+			return models.NewErrPrecondition()
+		}
+
+		// Restoring the subscription
+		query := `UPDATE subscriptions SET deleted_at = NULL, updated_at = $2, version = version + 1 WHERE id = $1 RETURNING ` + subscriptionColumns + `;`
+		after, err := scanSubscription(tx.QueryRowContext(ctx, query, before.ID, time.Now()))
+		if err != nil {
+			return models.NewErrInternalServer(err)
+		}
+		return recordEvent(ctx, tx, models.EventRestore, &before, &after)
+	})
+}
+
+// Purge permanently deletes the tenant's subscriptions which were moved to the trash before the time and returns their amount
+func (db *Database) Purge(ctx context.Context, before time.Time) (int, error) {
+	purged := 0
+	err := db.transaction(ctx, func(tx *sql.Tx) error {
+		query := `DELETE FROM subscriptions WHERE deleted_at < $1 AND tenant_id = $2 RETURNING ` + subscriptionColumns + `;`
+		rows, err := tx.QueryContext(ctx, query, before, models.TenantFromContext(ctx))
+		if err != nil {
+			return models.NewErrInternalServer(err)
+		}
+		var subscriptions []models.Subscription
+		for rows.Next() {
+			subscription, err := scanSubscription(rows)
+			if err != nil {
+				rows.Close()
+				return models.NewErrInternalServer(err)
+			}
+			subscriptions = append(subscriptions, subscription)
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return models.NewErrInternalServer(err)
+		}
+
+		// Recording the purged subscriptions, the events are being inserted after the rows are read
+		for i := range subscriptions {
+			if err = recordEvent(ctx, tx, models.EventPurge, &subscriptions[i], nil); err != nil {
+				return err
+			}
+		}
+		purged = len(subscriptions)
+		return nil
+	})
+	return purged, err
+}
+
+// TrashTenants returns the tenants which have subscriptions moved to the trash before the time, so the trash of each tenant can be purged
+func (db *Database) TrashTenants(ctx context.Context, before time.Time) ([]string, error) {
+	rows, err := db.QueryContext(ctx, `SELECT DISTINCT tenant_id FROM subscriptions WHERE deleted_at < $1 ORDER BY tenant_id;`, before)
+	if err != nil {
+		return nil, models.NewErrInternalServer(err)
+	}
+	defer rows.Close()
+
+	tenants := []string{}
+	for rows.Next() {
+		var tenant string
+		if err = rows.Scan(&tenant); err != nil {
+			return nil, models.NewErrInternalServer(err)
+		}
+		tenants = append(tenants, tenant)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, models.NewErrInternalServer(err)
+	}
+	return tenants, nil
+}
+
+// Inserting new subscription and recording its creation
+func create(ctx context.Context, q querier, subscription models.Subscription) (models.IDResponse, error) {
 	// Checking if the subscription is being already stored in the database
-	sub, err := db.Read(ctx, models.SubscriptionIdentifier{UserUUID: subscription.UserUUID, ServiceName: subscription.ServiceName})
+	sub, err := read(ctx, q, models.SubscriptionIdentifier{UserUUID: subscription.UserUUID, ServiceName: subscription.ServiceName})
 	if err != nil && !errors.Is(err, models.ErrNotFound) {
 		return models.IDResponse{}, models.NewErrInternalServer(err)
 	} else if !errors.Is(err, models.ErrNotFound) {
@@ -52,23 +235,31 @@ func (db *Database) Create(ctx context.Context, subscription models.Subscription
 	}
 
 	// Inserting subscription into the database
-	query := `INSERT INTO subscriptions (service_name, price, user_uuid, start_date, end_date, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id;`
-	err = db.QueryRowContext(ctx, query, subscription.ServiceName, subscription.Price, subscription.UserUUID, subscription.StartDate, subscription.EndDate,
-		time.Now(), time.Now()).Scan(&subscription.ID)
-	if err != nil {
+	query := `INSERT INTO subscriptions (service_name, price, currency, billing_unit, billing_interval, user_uuid, start_date, end_date, created_at, updated_at,
+		service_id, category, tenant_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING ` + subscriptionColumns + `;`
+	after, err := scanSubscription(q.QueryRowContext(ctx, query, subscription.ServiceName, subscription.Price, subscription.Currency, subscription.BillingUnit,
+		subscription.BillingInterval, subscription.UserUUID, subscription.StartDate, subscription.EndDate, time.Now(), time.Now(), serviceID(subscription),
+		subscription.Category, models.TenantFromContext(ctx)))
+	if violates(err, uniqueViolation) {
+		return models.IDResponse{}, models.NewErrConflict()
+	} else if err != nil {
 		return models.IDResponse{}, models.NewErrInternalServer(err)
 	}
-	return models.IDResponse{ID: subscription.ID}, nil
+	if after.Tags, err = storeTags(ctx, q, after.ID, subscription.Tags); err != nil {
+		return models.IDResponse{}, err
+	}
+	if err = recordEvent(ctx, q, models.EventCreate, nil, &after); err != nil {
+		return models.IDResponse{}, err
+	}
+	return models.IDResponse{ID: after.ID}, nil
 }
 
-// Read returns the subscription's info stored in the database. The subscription is being specified by its id or combination of user uuid and service name
-func (db *Database) Read(ctx context.Context, identifier models.SubscriptionIdentifier) (models.Subscription, error) {
-	// Getting subscription info
-	var subscription models.Subscription
-	query := `SELECT id, service_name, price, user_uuid, start_date, end_date, created_at, updated_at FROM subscriptions WHERE ($1 <= 0 OR id = $1) AND 
-		($2::uuid = '00000000-0000-0000-0000-000000000000'::uuid OR user_uuid = $2) AND ($3::text = ''::text OR service_name = $3);`
-	err := db.QueryRowContext(ctx, query, identifier.ID, identifier.UserUUID, identifier.ServiceName).Scan(&subscription.ID, &subscription.ServiceName, &subscription.Price,
-		&subscription.UserUUID, &subscription.StartDate, &subscription.EndDate, &subscription.CreatedAt, &subscription.UpdatedAt)
+// Getting the first subscription of the tenant matching the identifier
+func read(ctx context.Context, q querier, identifier models.SubscriptionIdentifier) (models.Subscription, error) {
+	query := `SELECT ` + subscriptionColumns + ` FROM subscriptions WHERE tenant_id = $4 AND ($1 <= 0 OR id = $1) AND 
+		($2::uuid = '00000000-0000-0000-0000-000000000000'::uuid OR user_uuid = $2) AND ($3::text = ''::text OR service_name = $3) AND deleted_at IS NULL;`
+	subscription, err := scanSubscription(q.QueryRowContext(ctx, query, identifier.ID, identifier.UserUUID, identifier.ServiceName,
+		models.TenantFromContext(ctx)))
 	if errors.Is(err, sql.ErrNoRows) {
 		return models.Subscription{}, models.NewErrNotFound()
 	} else if err != nil {
@@ -78,98 +269,330 @@ func (db *Database) Read(ctx context.Context, identifier models.SubscriptionIden
 	return subscription, nil
 }
 
-// Update updates subscription's info in the database. The subscription is being specified by its id
-func (db *Database) Update(ctx context.Context, subscription models.Subscription) error {
+// Getting the subscription of the tenant by its id and locking it until the end of the transaction. The subscription is being looked for either
+// in the trash or among not deleted ones
+func lock(ctx context.Context, q querier, id int, deleted bool) (models.Subscription, error) {
+	query := `SELECT ` + subscriptionColumns + ` FROM subscriptions WHERE id = $1 AND (deleted_at IS NOT NULL) = $2 AND tenant_id = $3 FOR UPDATE;`
+	subscription, err := scanSubscription(q.QueryRowContext(ctx, query, id, deleted, models.TenantFromContext(ctx)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Subscription{}, models.NewErrNotFound()
+	} else if err != nil {
+		return models.Subscription{}, models.NewErrInternalServer(err)
+	}
+	return subscription, nil
+}
+
+// Updating the subscription and recording its states before and after the update
+func update(ctx context.Context, q querier, subscription models.Subscription) error {
 	// Checking if the same subscription exists in the database
-	exists, err := db.Read(ctx, models.SubscriptionIdentifier{UserUUID: subscription.UserUUID, ServiceName: subscription.ServiceName})
+	exists, err := read(ctx, q, models.SubscriptionIdentifier{UserUUID: subscription.UserUUID, ServiceName: subscription.ServiceName})
 	if err != nil && !errors.Is(err, models.ErrNotFound) {
 		return err
 	} else if err == nil && subscription.ID != exists.ID {
 		return models.NewErrConflict()
 	}
 
-	// Getting database response
-	query := `UPDATE subscriptions SET service_name = $2, price = $3, user_uuid = $4, start_date = $5, end_date = $6, updated_at = $7 WHERE id = $1;`
-	_, err = db.ExecContext(ctx, query, subscription.ID, subscription.ServiceName, subscription.Price, subscription.UserUUID, subscription.StartDate, subscription.EndDate, time.Now())
+	// Checking if the subscription exists and its version matches
+	before, err := lock(ctx, q, subscription.ID, false)
 	if err != nil {
-		return models.NewErrInternalServer(err)
+		return err
+	}
+	if subscription.Version != 0 && subscription.Version != before.Version {
+		return models.NewErrPrecondition()
 	}
 
-	return nil
+	// Updating the subscription
+	query := `UPDATE subscriptions SET service_name = $2, price = $3, currency = $4, billing_unit = $5, billing_interval = $6, user_uuid = $7, start_date = $8,
+		end_date = $9, updated_at = $10, service_id = $11, category = $12, version = version + 1 WHERE id = $1 RETURNING ` + subscriptionColumns + `;`
+	after, err := scanSubscription(q.QueryRowContext(ctx, query, subscription.ID, subscription.ServiceName, subscription.Price, subscription.Currency,
+		subscription.BillingUnit, subscription.BillingInterval, subscription.UserUUID, subscription.StartDate, subscription.EndDate, time.Now(),
+		serviceID(subscription), subscription.Category))
+	if violates(err, uniqueViolation) {
+		return models.NewErrConflict()
+	} else if err != nil {
+		return models.NewErrInternalServer(err)
+	}
+	if after.Tags, err = storeTags(ctx, q, after.ID, subscription.Tags); err != nil {
+		return err
+	}
+	return recordEvent(ctx, q, models.EventUpdate, &before, &after)
 }
 
-// Delete deletes a subscription from the database. The subscriptions can be specified by its id or combination of user uuid and service name
-func (db *Database) Delete(ctx context.Context, identifier models.SubscriptionIdentifier) error {
-	// Checking if the subscription exists in the database
-	subscription, err := db.Read(ctx, identifier)
+// Moving the subscription to the trash and recording its states before and after the deletion
+func remove(ctx context.Context, q querier, identifier models.SubscriptionIdentifier) error {
+	// Checking if the subscription exists and its version matches
+	subscription, err := read(ctx, q, identifier)
 	if err != nil {
 		return err
 	}
+	before, err := lock(ctx, q, subscription.ID, false)
+	if err != nil {
+		return err
+	}
+	if identifier.Version != 0 && identifier.Version != before.Version {
+		return models.NewErrPrecondition()
+	}
 
-	// Deleting from the database
-	req := `DELETE FROM subscriptions WHERE id = $1;`
-	_, err = db.ExecContext(ctx, req, subscription.ID)
+	// Marking the subscription as deleted
+	query := `UPDATE subscriptions SET deleted_at = $2, updated_at = $2, version = version + 1 WHERE id = $1 RETURNING ` + subscriptionColumns + `;`
+	after, err := scanSubscription(q.QueryRowContext(ctx, query, before.ID, time.Now()))
 	if err != nil {
 		return models.NewErrInternalServer(err)
 	}
-
-	return nil
+	return recordEvent(ctx, q, models.EventDelete, &before, &after)
 }
 
-// List returns an array of subscriptions filtered by user uuid and service name. The list of subscriptions can be filtered by the period, user uuid and service name
+// List returns an array of subscriptions sorted by the columns and then by id. The list of subscriptions can be filtered by the period, user uuid and service name. The page
+// starts next to the cursor if it is provided
 func (db *Database) List(ctx context.Context, params models.SubscriptionsWithinPeriod) ([]models.Subscription, error) {
 	// Getting subscritions from the database
-	var rows *sql.Rows
-	query := `SELECT id, service_name, price, user_uuid, start_date, end_date, created_at, updated_at FROM subscriptions
-		WHERE ($1::uuid = '00000000-0000-0000-0000-000000000000'::uuid OR user_uuid = $1) AND ($2::text = ''::text OR service_name = $2) and 
-		($4::timestamp IS NULL OR start_date <= $4) AND ($3::timestamp IS NULL OR end_date IS NULL OR end_date >= $3) ORDER BY id LIMIT $5 OFFSET $6;`
-	rows, err := db.QueryContext(ctx, query, params.UserUUID, params.ServiceName, params.StartDate, params.EndDate, params.Limit, params.Offset)
+	var args arguments
+	order, backward := params.Order(), params.Cursor != nil && params.Cursor.Backward
+	conditions := filterSubscriptions(ctx, params, &args)
+	if params.Cursor != nil {
+		cursor, err := params.Cursor.Subscription(order)
+		if err != nil {
+			return []models.Subscription{}, models.NewErrInternalServer(err)
+		}
+		conditions += " AND " + afterCursor(order, cursor, backward, &args)
+	}
+	query := `SELECT ` + subscriptionColumns + ` FROM subscriptions WHERE ` + conditions + `
+		ORDER BY ` + orderSubscriptions(order, backward) + ` LIMIT ` + args.add(params.Limit) + ` OFFSET ` + args.add(params.Offset) + `;`
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return []models.Subscription{}, err
+		return []models.Subscription{}, models.NewErrInternalServer(err)
 	}
 	defer rows.Close()
 
 	// Parsing info to subscription type
 	var subscriptions []models.Subscription
 	for rows.Next() {
-		var subscription models.Subscription
-		err = rows.Scan(&subscription.ID, &subscription.ServiceName, &subscription.Price, &subscription.UserUUID, &subscription.StartDate, &subscription.EndDate,
-			&subscription.CreatedAt, &subscription.UpdatedAt)
+		subscription, err := scanSubscription(rows)
 		if err != nil {
 			return []models.Subscription{}, models.NewErrInternalServer(err)
 		}
 		subscriptions = append(subscriptions, subscription)
 	}
 
+	// The page read backwards is being returned in the order of the list
+	if backward {
+		slices.Reverse(subscriptions)
+	}
 	return subscriptions, nil
 }
 
-// Summary return amount of subscriptions within the provided period and total amount that was payed.
-// The subscriptions can be filtered by the period, user uuid and service name
+// Count returns amount of subscriptions filtered the same way as the list
+func (db *Database) Count(ctx context.Context, params models.SubscriptionsWithinPeriod) (int, error) {
+	var args arguments
+	var count int
+	query := `SELECT COUNT(*) FROM subscriptions WHERE ` + filterSubscriptions(ctx, params, &args) + `;`
+	if err := db.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, models.NewErrInternalServer(err)
+	}
+	return count, nil
+}
+
+// Export passes each subscription filtered the same way as the list to the function in order of id. The export stops on the function's error
+func (db *Database) Export(ctx context.Context, params models.SubscriptionsWithinPeriod, fn func(models.Subscription) error) error {
+	var args arguments
+	query := `SELECT ` + subscriptionColumns + ` FROM subscriptions WHERE ` + filterSubscriptions(ctx, params, &args) + ` ORDER BY id;`
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return models.NewErrInternalServer(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		subscription, err := scanSubscription(rows)
+		if err != nil {
+			return models.NewErrInternalServer(err)
+		}
+		if err = fn(subscription); err != nil {
+			return err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return models.NewErrInternalServer(err)
+	}
+	return nil
+}
+
+// Summary return amount of subscriptions within the provided period and total amount that was payed in each currency. The price effective at each
+// billing date of the subscription within the period is being charged. The subscriptions can be filtered by the period, user uuid and service name
+// and grouped by service name, user uuid, month, category and tag
 func (db *Database) Summary(ctx context.Context, params models.SubscriptionsWithinPeriod) (models.SummaryResponse, error) {
+	summary := models.SummaryResponse{Months: models.Months(params.StartDate.Time, params.EndDate.Time), Totals: []models.CurrencyTotal{}}
+
+	// Getting grand total
+	groups, err := db.summaryGroups(ctx, params, nil)
+	if err != nil {
+		return models.SummaryResponse{}, err
+	}
+	if len(groups) > 0 {
+		summary.Amount, summary.Totals = groups[0].Amount, groups[0].Totals
+	}
+
+	// Getting totals of each group
+	if len(params.GroupBy) > 0 {
+		summary.Groups, err = db.summaryGroups(ctx, params, params.GroupBy)
+		if err != nil {
+			return models.SummaryResponse{}, err
+		}
+	}
+	return summary, nil
+}
+
+// Expressions of the fields the summary can be grouped by and ordered by
+var summaryGroupings = map[string]struct{ column, order string }{
+	models.GroupByServiceName: {"service_name", "service_name"},
+	models.GroupByUserUUID:    {"user_uuid::text", "user_uuid"},
+	models.GroupByMonth:       {"to_char(period.month, 'MM-YYYY')", "period.month"},
+	models.GroupByCategory:    {"category", "category"},
+	models.GroupByTag:         {"tagged.tag", "tagged.tag"},
+}
+
+// Getting totals in each currency of the subscriptions grouped by the fields
+func (db *Database) summaryGroups(ctx context.Context, params models.SubscriptionsWithinPeriod, groupBy []string) ([]models.SummaryGroup, error) {
+	var args arguments
+	start, end := args.add(params.StartDate), args.add(params.EndDate)
+
+	// Charges are being counted within the period or within each month of the period if the summary is grouped by month
+	columns, orders := []string{}, []string{}
+	joins, window := "", "charged_at >= "+start+"::timestamp"
+	for _, field := range groupBy {
+		columns = append(columns, summaryGroupings[field].column+", ")
+		orders = append(orders, summaryGroupings[field].order+", ")
+		if field == models.GroupByMonth {
+			joins += `CROSS JOIN LATERAL generate_series(GREATEST(start_date, ` + start + `::timestamp), LEAST(COALESCE(end_date, ` + end + `::timestamp), ` +
+				end + `::timestamp), INTERVAL '1 month') AS period(month) `
+			window = "charged_at >= period.month AND charged_at < period.month + INTERVAL '1 month'"
+		}
+		// The subscription is counted in the group of each of its tags
+		if field == models.GroupByTag {
+			joins += `CROSS JOIN LATERAL unnest(COALESCE(NULLIF(` + subscriptionTags + `, '{}'), ARRAY['']::text[])) AS tagged(tag) `
+		}
+	}
+
 	// Getting subscritions from the database
-	var amount, months, total int
-	query := `SELECT 
-		COUNT(*) AS amount,
-		(EXTRACT(YEAR FROM $4::timestamp) - 
-			EXTRACT(YEAR FROM $3::timestamp)) * 12 +
-		EXTRACT(MONTH FROM $4::timestamp) - 
-			EXTRACT(MONTH FROM $3::timestamp) + 1 AS months,
-		SUM(
-			((EXTRACT(YEAR FROM LEAST(COALESCE(end_date, $4::timestamp), $4::timestamp)) - 
-                EXTRACT(YEAR FROM GREATEST(start_date, $3::timestamp))) * 12 +
-			EXTRACT(MONTH FROM LEAST(COALESCE(end_date, $4::timestamp), $4::timestamp)) - 
-                EXTRACT(MONTH FROM GREATEST(start_date, $3::timestamp)) + 1) 
-			* price) AS total
+	query := `SELECT ` + strings.Join(columns, "") + `currency, COUNT(*) AS amount, COALESCE(SUM(billing.total), 0) AS total
 		FROM subscriptions
-		WHERE 
-			($1::uuid = '00000000-0000-0000-0000-000000000000'::uuid OR user_uuid = $1)
-			AND ($2::text = ''::text OR service_name = $2)
-			AND start_date <= $4 
-			AND (end_date IS NULL OR end_date >= $3);`
-	err := db.QueryRowContext(ctx, query, params.UserUUID, params.ServiceName, params.StartDate, params.EndDate).Scan(&amount, &months, &total)
+		` + joins + `
+		CROSS JOIN LATERAL (
+			SELECT SUM(` + chargedPrice + `) AS total
+			FROM generate_series(start_date, LEAST(COALESCE(end_date, ` + end + `::timestamp), ` + end + `::timestamp) + INTERVAL '1 month' - INTERVAL '1 day', ` +
+		billingStep + `) AS charged_at
+			WHERE ` + window + `
+		) AS billing
+		WHERE ` + filterSubscriptions(ctx, params, &args) + `
+		GROUP BY ` + strings.Join(orders, "") + `currency
+		ORDER BY ` + strings.Join(orders, "") + `currency;`
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, models.NewErrInternalServer(err)
+	}
+	defer rows.Close()
+
+	// Parsing totals, the rows of the same group in different currencies are being merged
+	groups := []models.SummaryGroup{}
+	for rows.Next() {
+		values := make([]string, len(groupBy))
+		var total models.CurrencyTotal
+		dest := []any{}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		if err = rows.Scan(append(dest, &total.Currency, &total.Amount, &total.Total)...); err != nil {
+			return nil, models.NewErrInternalServer(err)
+		}
+
+		group := make(map[string]string, len(groupBy))
+		for i, field := range groupBy {
+			group[field] = values[i]
+		}
+		if len(groups) == 0 || !maps.Equal(groups[len(groups)-1].Group, group) {
+			groups = append(groups, models.SummaryGroup{Group: group, Totals: []models.CurrencyTotal{}})
+		}
+		groups[len(groups)-1].Amount += total.Amount
+		groups[len(groups)-1].Totals = append(groups[len(groups)-1].Totals, total)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, models.NewErrInternalServer(err)
+	}
+	return groups, nil
+}
+
+// Truncation field and length of the time series buckets
+var timeseriesBuckets = map[string]struct{ field, step string }{
+	models.BucketWeek:    {"week", "1 week"},
+	models.BucketMonth:   {"month", "1 month"},
+	models.BucketQuarter: {"quarter", "3 months"},
+	models.BucketYear:    {"year", "1 year"},
+}
+
+// Timeseries returns amount of active, new and cancelled subscriptions and total amount that was payed in each currency within each bucket of the period.
+// The subscriptions can be filtered by the period, user uuid and service name
+func (db *Database) Timeseries(ctx context.Context, params models.SubscriptionsWithinPeriod) ([]models.TimeseriesBucket, error) {
+	var args arguments
+	start, end := args.add(params.StartDate), args.add(params.EndDate)
+	bucket := timeseriesBuckets[params.Bucket]
+	field, step := args.add(bucket.field), args.add(bucket.step)
+
+	// The subscription is being paid until the last day of its end month
+	query := `WITH buckets AS (
+			SELECT
+				GREATEST(bucket, ` + start + `::timestamp) AS bucket_start,
+				LEAST(bucket + ` + step + `::interval, ` + end + `::timestamp + INTERVAL '1 month') - INTERVAL '1 day' AS bucket_end
+			FROM generate_series(date_trunc(` + field + `, ` + start + `::timestamp), ` + end + `::timestamp + INTERVAL '1 month' - INTERVAL '1 day', ` +
+		step + `::interval) AS bucket
+		)
+		SELECT
+			to_char(bucket_start, 'DD-MM-YYYY'),
+			to_char(bucket_end, 'DD-MM-YYYY'),
+			currency,
+			COUNT(id) AS active,
+			COUNT(id) FILTER (WHERE start_date >= bucket_start) AS new,
+			COUNT(id) FILTER (WHERE end_date + INTERVAL '1 month' - INTERVAL '1 day' <= bucket_end) AS cancelled,
+			COALESCE(SUM(billing.total), 0) AS total
+		FROM buckets
+		LEFT JOIN subscriptions ON ` + filterSubscriptions(ctx, params, &args) + ` AND start_date <= bucket_end
+			AND (end_date IS NULL OR end_date + INTERVAL '1 month' - INTERVAL '1 day' >= bucket_start)
+		LEFT JOIN LATERAL (
+			SELECT SUM(` + chargedPrice + `) AS total
+			FROM generate_series(start_date, LEAST(COALESCE(end_date + INTERVAL '1 month' - INTERVAL '1 day', bucket_end), bucket_end), ` + billingStep + `) AS charged_at
+			WHERE charged_at >= bucket_start
+		) AS billing ON TRUE
+		GROUP BY bucket_start, bucket_end, currency
+		ORDER BY bucket_start, currency;`
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return models.SummaryResponse{}, models.NewErrInternalServer(err)
+		return nil, models.NewErrInternalServer(err)
+	}
+	defer rows.Close()
+
+	// Parsing buckets, the rows of the same bucket in different currencies are being merged
+	buckets := []models.TimeseriesBucket{}
+	for rows.Next() {
+		var bucketStart, bucketEnd string
+		var currency sql.NullString
+		var active, added, cancelled, total int
+		if err = rows.Scan(&bucketStart, &bucketEnd, &currency, &active, &added, &cancelled, &total); err != nil {
+			return nil, models.NewErrInternalServer(err)
+		}
+
+		if len(buckets) == 0 || buckets[len(buckets)-1].Start != bucketStart {
+			buckets = append(buckets, models.TimeseriesBucket{Start: bucketStart, End: bucketEnd, Totals: []models.CurrencyTotal{}})
+		}
+		if !currency.Valid {
+			continue
+		}
+		last := &buckets[len(buckets)-1]
+		last.Active += active
+		last.New += added
+		last.Cancelled += cancelled
+		last.Totals = append(last.Totals, models.CurrencyTotal{Currency: currency.String, Amount: active, Total: total})
+	}
+	if err = rows.Err(); err != nil {
+		return nil, models.NewErrInternalServer(err)
 	}
-	return models.SummaryResponse{Amount: amount, Months: months, Total: total}, nil
+	return buckets, nil
 }
@@ -0,0 +1,119 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/middelmatigheid/subscriptions-api/internal/models"
+)
+
+// History returns the changes of the tenant's subscription in order they were made
+func (db *Database) History(ctx context.Context, id int) ([]models.SubscriptionEvent, error) {
+	query := `SELECT id, subscription_id, action, before, after, price, actor, actor_label, request_id, created_at, tenant_id FROM subscription_events
+		WHERE tenant_id = $2 AND subscription_id = $1 ORDER BY id;`
+	rows, err := db.QueryContext(ctx, query, id, models.TenantFromContext(ctx))
+	if err != nil {
+		return nil, models.NewErrInternalServer(err)
+	}
+	defer rows.Close()
+
+	// Parsing the events, the states are being stored as json
+	events := []models.SubscriptionEvent{}
+	for rows.Next() {
+		var event models.SubscriptionEvent
+		var before, after, price []byte
+		if err = rows.Scan(&event.ID, &event.SubscriptionID, &event.Action, &before, &after, &price, &event.Actor, &event.ActorLabel, &event.RequestID, &event.CreatedAt,
+			&event.TenantID); err != nil {
+			return nil, models.NewErrInternalServer(err)
+		}
+		if event.Before, err = unmarshalSnapshot(before); err != nil {
+			return nil, err
+		}
+		if event.After, err = unmarshalSnapshot(after); err != nil {
+			return nil, err
+		}
+		if price != nil {
+			event.Price = &models.PriceChange{}
+			if err = json.Unmarshal(price, event.Price); err != nil {
+				return nil, models.NewErrInternalServer(err)
+			}
+		}
+		events = append(events, event)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, models.NewErrInternalServer(err)
+	}
+	if len(events) == 0 {
+		return nil, models.NewErrNotFound()
+	}
+	return events, nil
+}
+
+// Recording the change of the subscription made by the actor within the request of the context. The event belongs to the subscription's tenant
+func recordEvent(ctx context.Context, q querier, action string, before, after *models.Subscription) error {
+	return insertEvent(ctx, q, action, before, after, nil)
+}
+
+// Recording the price scheduled for the subscription, the state of the subscription isn't changed
+func recordPriceEvent(ctx context.Context, q querier, subscription models.Subscription, change models.PriceChange) error {
+	return insertEvent(ctx, q, models.EventSchedulePrice, &subscription, &subscription, &change)
+}
+
+// Inserting the event with the states of the subscription and the scheduled price
+func insertEvent(ctx context.Context, q querier, action string, before, after *models.Subscription, price *models.PriceChange) error {
+	id, tenant := 0, models.TenantFromContext(ctx)
+	if before != nil {
+		id, tenant = before.ID, before.TenantID
+	} else if after != nil {
+		id, tenant = after.ID, after.TenantID
+	}
+	beforeJSON, err := marshalSnapshot(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := marshalSnapshot(after)
+	if err != nil {
+		return err
+	}
+	var priceJSON any
+	if price != nil {
+		b, err := json.Marshal(price)
+		if err != nil {
+			return models.NewErrInternalServer(err)
+		}
+		priceJSON = string(b)
+	}
+
+	query := `INSERT INTO subscription_events (subscription_id, action, before, after, price, actor, actor_label, request_id, tenant_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);`
+	_, err = q.ExecContext(ctx, query, id, action, beforeJSON, afterJSON, priceJSON, models.ActorFromContext(ctx), models.ActorLabelFromContext(ctx),
+		models.RequestIDFromContext(ctx), tenant)
+	if err != nil {
+		return models.NewErrInternalServer(err)
+	}
+	return nil
+}
+
+// Converting the state of the subscription to json, null state is being stored as NULL
+func marshalSnapshot(subscription *models.Subscription) (any, error) {
+	if subscription == nil {
+		return nil, nil
+	}
+	snapshot, err := json.Marshal(subscription)
+	if err != nil {
+		return nil, models.NewErrInternalServer(err)
+	}
+	return string(snapshot), nil
+}
+
+// Parsing the state of the subscription from json
+func unmarshalSnapshot(snapshot []byte) (*models.Subscription, error) {
+	if snapshot == nil {
+		return nil, nil
+	}
+	var subscription models.Subscription
+	if err := json.Unmarshal(snapshot, &subscription); err != nil {
+		return nil, models.NewErrInternalServer(err)
+	}
+	return &subscription, nil
+}
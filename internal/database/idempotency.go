@@ -0,0 +1,88 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/middelmatigheid/subscriptions-api/internal/models"
+)
+
+// ReserveIdempotencyKey stores the key of the request being processed, the keys of different tenants and of different clients of the tenant don't
+// collide. The key created before the expiration time is being replaced as if it was never used. If the key is already reserved its record is
+// returned with the conflict error
+func (db *Database) ReserveIdempotencyKey(ctx context.Context, record models.IdempotencyRecord, expired time.Time) (models.IdempotencyRecord, error) {
+	// Reserving the key
+	record.CreatedAt = time.Now()
+	tenant, subject := models.TenantFromContext(ctx), models.SubjectFromContext(ctx)
+	query := `INSERT INTO idempotency_keys (key, request_hash, created_at, tenant_id, subject) VALUES ($1, $2, $3, $4, $6)
+		ON CONFLICT (tenant_id, subject, key) DO UPDATE SET request_hash = EXCLUDED.request_hash, status = 0, headers = NULL, body = NULL,
+		created_at = EXCLUDED.created_at WHERE idempotency_keys.created_at < $5;`
+	res, err := db.ExecContext(ctx, query, record.Key, record.RequestHash, record.CreatedAt, tenant, expired, subject)
+	if err != nil {
+		return models.IdempotencyRecord{}, models.NewErrInternalServer(err)
+	}
+	if rows, err := res.RowsAffected(); err != nil {
+		return models.IdempotencyRecord{}, models.NewErrInternalServer(err)
+	} else if rows == 1 {
+		return record, nil
+	}
+
+	// Getting the record of the reserved key
+	var existing models.IdempotencyRecord
+	var headers []byte
+	query = `SELECT key, request_hash, status, headers, body, created_at FROM idempotency_keys WHERE tenant_id = $2 AND subject = $3 AND key = $1;`
+	err = db.QueryRowContext(ctx, query, record.Key, tenant, subject).Scan(&existing.Key, &existing.RequestHash, &existing.Status, &headers, &existing.Body, &existing.CreatedAt)
+	if err != nil {
+		return models.IdempotencyRecord{}, models.NewErrInternalServer(err)
+	}
+	if headers != nil {
+		if err = json.Unmarshal(headers, &existing.Headers); err != nil {
+			return models.IdempotencyRecord{}, models.NewErrInternalServer(err)
+		}
+	}
+	return existing, models.NewErrConflict()
+}
+
+// SaveIdempotencyResponse stores the response to the request made with the reserved key
+func (db *Database) SaveIdempotencyResponse(ctx context.Context, record models.IdempotencyRecord) error {
+	headers, err := json.Marshal(record.Headers)
+	if err != nil {
+		return models.NewErrInternalServer(err)
+	}
+	query := `UPDATE idempotency_keys SET status = $2, headers = $3, body = $4 WHERE tenant_id = $5 AND subject = $6 AND key = $1;`
+	res, err := db.ExecContext(ctx, query, record.Key, record.Status, string(headers), record.Body, models.TenantFromContext(ctx),
+		models.SubjectFromContext(ctx))
+	if err != nil {
+		return models.NewErrInternalServer(err)
+	}
+	if rows, err := res.RowsAffected(); err != nil {
+		return models.NewErrInternalServer(err)
+	} else if rows == 0 {
+		return models.NewErrInternalServer(errors.New("Idempotency key is not reserved"))
+	}
+	return nil
+}
+
+// ReleaseIdempotencyKey drops the key so the request can be made with it again
+func (db *Database) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	query := `DELETE FROM idempotency_keys WHERE tenant_id = $2 AND subject = $3 AND key = $1;`
+	if _, err := db.ExecContext(ctx, query, key, models.TenantFromContext(ctx), models.SubjectFromContext(ctx)); err != nil {
+		return models.NewErrInternalServer(err)
+	}
+	return nil
+}
+
+// PurgeIdempotencyKeys drops the keys of all the tenants and clients created before the expiration time and returns their amount
+func (db *Database) PurgeIdempotencyKeys(ctx context.Context, expired time.Time) (int, error) {
+	res, err := db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE created_at < $1;`, expired)
+	if err != nil {
+		return 0, models.NewErrInternalServer(err)
+	}
+	purged, err := res.RowsAffected()
+	if err != nil {
+		return 0, models.NewErrInternalServer(err)
+	}
+	return int(purged), nil
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/middelmatigheid/subscriptions-api/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// Columns of the api keys table in order they are being scanned
+const apiKeyColumns = `id, name, prefix, hash, scopes, expires_at, last_used_at, created_at, revoked_at, tenant_id`
+
+// Scanning the row into api key type
+func scanAPIKey(row interface{ Scan(...any) error }) (models.APIKey, error) {
+	var key models.APIKey
+	err := row.Scan(&key.ID, &key.Name, &key.Prefix, &key.Hash, pq.Array(&key.Scopes), &key.ExpiresAt, &key.LastUsedAt, &key.CreatedAt, &key.RevokedAt,
+		&key.TenantID)
+	return key, err
+}
+
+// CreateAPIKey stores hash of the new api key of the tenant and returns its id
+func (db *Database) CreateAPIKey(ctx context.Context, key models.APIKey) (models.IDResponse, error) {
+	var res models.IDResponse
+	query := `INSERT INTO api_keys (name, prefix, hash, scopes, expires_at, created_at, tenant_id) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id;`
+	err := db.QueryRowContext(ctx, query, key.Name, key.Prefix, key.Hash, pq.Array(key.Scopes), key.ExpiresAt, time.Now(), key.TenantID).Scan(&res.ID)
+	if violates(err, uniqueViolation) {
+		return models.IDResponse{}, models.NewErrConflict()
+	} else if err != nil {
+		return models.IDResponse{}, models.NewErrInternalServer(err)
+	}
+	return res, nil
+}
+
+// ReadAPIKey returns the api key specified by the hash of the key, expired and revoked keys are returned as well. The key is looked for among all
+// the tenants since the tenant is resolved from it
+func (db *Database) ReadAPIKey(ctx context.Context, hash string) (models.APIKey, error) {
+	key, err := scanAPIKey(db.QueryRowContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE hash = $1;`, hash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.APIKey{}, models.NewErrNotFound()
+	} else if err != nil {
+		return models.APIKey{}, models.NewErrInternalServer(err)
+	}
+	return key, nil
+}
+
+// ListAPIKeys returns the api keys of the tenant ordered by id, the hashes are not being returned
+func (db *Database) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	rows, err := db.QueryContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE tenant_id = $1 ORDER BY id;`, models.TenantFromContext(ctx))
+	if err != nil {
+		return nil, models.NewErrInternalServer(err)
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, models.NewErrInternalServer(err)
+		}
+		key.Hash = ""
+		keys = append(keys, key)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, models.NewErrInternalServer(err)
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey revokes the api key of the tenant specified by its id, the time of the first revocation is kept
+func (db *Database) RevokeAPIKey(ctx context.Context, id int) error {
+	query := `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, $2) WHERE id = $1 AND tenant_id = $3;`
+	res, err := db.ExecContext(ctx, query, id, time.Now(), models.TenantFromContext(ctx))
+	if err != nil {
+		return models.NewErrInternalServer(err)
+	}
+	if rows, err := res.RowsAffected(); err != nil {
+		return models.NewErrInternalServer(err)
+	} else if rows == 0 {
+		return models.NewErrNotFound()
+	}
+	return nil
+}
+
+// TouchAPIKey stores the time the api key was last used at
+func (db *Database) TouchAPIKey(ctx context.Context, id int, usedAt time.Time) error {
+	if _, err := db.ExecContext(ctx, `UPDATE api_keys SET last_used_at = $2 WHERE id = $1;`, id, usedAt); err != nil {
+		return models.NewErrInternalServer(err)
+	}
+	return nil
+}
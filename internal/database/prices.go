@@ -0,0 +1,56 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/middelmatigheid/subscriptions-api/internal/models"
+)
+
+// Price of the subscription effective at its billing date, the subscription's own price is charged until the first change
+const chargedPrice = `COALESCE((SELECT prices.price FROM subscription_prices AS prices WHERE prices.subscription_id = subscriptions.id
+			AND prices.effective_from <= charged_at ORDER BY prices.effective_from DESC LIMIT 1), subscriptions.price)`
+
+// SchedulePrice sets the price of the tenant's subscription effective from the month, the price already scheduled for the month is being replaced.
+// The scheduled price is recorded in the subscription's history
+func (db *Database) SchedulePrice(ctx context.Context, id int, change models.PriceChange) error {
+	return db.transaction(ctx, func(tx *sql.Tx) error {
+		subscription, err := lock(ctx, tx, id, false)
+		if err != nil {
+			return err
+		}
+
+		query := `INSERT INTO subscription_prices (subscription_id, price, effective_from, created_at) VALUES ($1, $2, $3, $4)
+			ON CONFLICT (subscription_id, effective_from) DO UPDATE SET price = EXCLUDED.price, created_at = EXCLUDED.created_at RETURNING created_at;`
+		if err = tx.QueryRowContext(ctx, query, id, change.Price, change.EffectiveFrom, time.Now()).Scan(&change.CreatedAt); err != nil {
+			return models.NewErrInternalServer(err)
+		}
+		return recordPriceEvent(ctx, tx, subscription, change)
+	})
+}
+
+// Prices returns the price changes of the tenant's subscription ordered by the month they are effective from
+func (db *Database) Prices(ctx context.Context, id int) ([]models.PriceChange, error) {
+	query := `SELECT prices.price, prices.effective_from, prices.created_at FROM subscription_prices AS prices
+		JOIN subscriptions ON subscriptions.id = prices.subscription_id WHERE prices.subscription_id = $1 AND subscriptions.tenant_id = $2
+		ORDER BY prices.effective_from;`
+	rows, err := db.QueryContext(ctx, query, id, models.TenantFromContext(ctx))
+	if err != nil {
+		return nil, models.NewErrInternalServer(err)
+	}
+	defer rows.Close()
+
+	changes := []models.PriceChange{}
+	for rows.Next() {
+		var change models.PriceChange
+		if err = rows.Scan(&change.Price, &change.EffectiveFrom, &change.CreatedAt); err != nil {
+			return nil, models.NewErrInternalServer(err)
+		}
+		changes = append(changes, change)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, models.NewErrInternalServer(err)
+	}
+	return changes, nil
+}
@@ -0,0 +1,164 @@
+package database
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/middelmatigheid/subscriptions-api/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// Arguments of the query being built
+type arguments []any
+
+// Adding the value to the arguments and returning its placeholder
+func (a *arguments) add(value any) string {
+	*a = append(*a, value)
+	return "$" + strconv.Itoa(len(*a))
+}
+
+// Building conditions for the subscriptions of the tenant filtered by user uuids, service name, price, dates, category, tags and intersecting the
+// period. Only provided filters are being added to the conditions so the indexes can be used. Deleted subscriptions are being excluded unless they
+// are requested
+func filterSubscriptions(ctx context.Context, params models.SubscriptionsWithinPeriod, args *arguments) string {
+	conditions := []string{"tenant_id = " + args.add(models.TenantFromContext(ctx))}
+	if params.OnlyDeleted {
+		conditions = append(conditions, "deleted_at IS NOT NULL")
+	} else if !params.IncludeDeleted {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+	if len(params.UserUUIDs) == 1 {
+		conditions = append(conditions, "user_uuid = "+args.add(params.UserUUIDs[0]))
+	} else if len(params.UserUUIDs) > 1 {
+		uuids := make([]string, len(params.UserUUIDs))
+		for i, userUUID := range params.UserUUIDs {
+			uuids[i] = userUUID.String()
+		}
+		conditions = append(conditions, "user_uuid = ANY("+args.add(pq.Array(uuids))+"::uuid[])")
+	}
+	if params.ServiceName != "" {
+		conditions = append(conditions, matchServiceName(params, args))
+	}
+	if params.PriceMin > 0 {
+		conditions = append(conditions, "price >= "+args.add(params.PriceMin))
+	}
+	if params.PriceMax > 0 {
+		conditions = append(conditions, "price <= "+args.add(params.PriceMax))
+	}
+	if params.EndDate.Valid {
+		conditions = append(conditions, "start_date <= "+args.add(params.EndDate))
+	}
+	if params.StartDate.Valid {
+		conditions = append(conditions, "(end_date IS NULL OR end_date >= "+args.add(params.StartDate)+")")
+	}
+	if params.ActiveOn.Valid {
+		activeOn := args.add(params.ActiveOn)
+		conditions = append(conditions, "start_date <= "+activeOn, "(end_date IS NULL OR end_date >= "+activeOn+")")
+	}
+	if params.HasEndDate != nil && *params.HasEndDate {
+		conditions = append(conditions, "end_date IS NOT NULL")
+	} else if params.HasEndDate != nil {
+		conditions = append(conditions, "end_date IS NULL")
+	}
+	if params.CreatedAfter.Valid {
+		conditions = append(conditions, "created_at > "+args.add(params.CreatedAfter))
+	}
+	if params.UpdatedAfter.Valid {
+		conditions = append(conditions, "updated_at > "+args.add(params.UpdatedAfter))
+	}
+	if params.Category != "" {
+		conditions = append(conditions, "category = "+args.add(params.Category))
+	}
+	if len(params.Tags) > 0 {
+		conditions = append(conditions, filterTags(params.Tags, args))
+	}
+	return strings.Join(conditions, " AND ")
+}
+
+// Building condition for the service name. Prefix is matched by the pattern index and substring by the trigram index, case-insensitive match
+// uses the index of the lowered name
+func matchServiceName(params models.SubscriptionsWithinPeriod, args *arguments) string {
+	column, pattern := "service_name", params.ServiceName
+	if params.IgnoreCase {
+		column, pattern = "lower(service_name)", strings.ToLower(pattern)
+	}
+	escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(pattern)
+	switch params.ServiceNameMatch {
+	case models.MatchPrefix:
+		return column + " LIKE " + args.add(escaped+"%")
+	case models.MatchContains:
+		if params.IgnoreCase {
+			return "service_name ILIKE " + args.add("%"+escaped+"%")
+		}
+		return "service_name LIKE " + args.add("%"+escaped+"%")
+	}
+	return column + " = " + args.add(pattern)
+}
+
+// Getting expression the list is sorted by. Subscriptions without the end date are the last ones in ascending order
+func sortExpression(column string) string {
+	if column == "end_date" {
+		return "COALESCE(end_date, 'infinity'::timestamp)"
+	}
+	return column
+}
+
+// Getting value of the subscription's column compared with the sort expression
+func sortArgument(subscription models.Subscription, column string) any {
+	switch column {
+	case "service_name":
+		return subscription.ServiceName
+	case "price":
+		return subscription.Price
+	case "currency":
+		return subscription.Currency
+	case "user_uuid":
+		return subscription.UserUUID
+	case "start_date":
+		return subscription.StartDate.Time
+	case "end_date":
+		if !subscription.EndDate.Valid {
+			return "infinity"
+		}
+		return subscription.EndDate.Time
+	case "created_at":
+		return subscription.CreatedAt.Time
+	case "updated_at":
+		return subscription.UpdatedAt.Time
+	}
+	return subscription.ID
+}
+
+// Building order of the list. The order is being reversed to read the list backwards
+func orderSubscriptions(order []models.SortKey, backward bool) string {
+	columns := make([]string, len(order))
+	for i, key := range order {
+		direction := "ASC"
+		if key.Desc != backward {
+			direction = "DESC"
+		}
+		columns[i] = sortExpression(key.Column) + " " + direction
+	}
+	return strings.Join(columns, ", ")
+}
+
+// Building condition for the subscriptions next to the cursor in the sorted list. The subscription is next to the cursor if it goes after the
+// cursor by some column and is equal to the cursor by all the previous ones
+func afterCursor(order []models.SortKey, cursor models.Subscription, backward bool, args *arguments) string {
+	alternatives := make([]string, len(order))
+	for i, key := range order {
+		conditions := make([]string, 0, i+1)
+		for _, previous := range order[:i] {
+			conditions = append(conditions, sortExpression(previous.Column)+" = "+args.add(sortArgument(cursor, previous.Column)))
+		}
+		operator := ">"
+		if key.Desc != backward {
+			operator = "<"
+		}
+		conditions = append(conditions, sortExpression(key.Column)+" "+operator+" "+args.add(sortArgument(cursor, key.Column)))
+		alternatives[i] = "(" + strings.Join(conditions, " AND ") + ")"
+	}
+	return "(" + strings.Join(alternatives, " OR ") + ")"
+}
@@ -0,0 +1,262 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/middelmatigheid/subscriptions-api/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// Columns of the services table in order they are being scanned
+const serviceColumns = `id, name, aliases, category, default_price, currency, created_at, updated_at, tenant_id`
+
+// Codes of the violated constraints
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+// Scanning the row into service type
+func scanService(row interface{ Scan(...any) error }) (models.CatalogService, error) {
+	var service models.CatalogService
+	err := row.Scan(&service.ID, &service.Name, pq.Array(&service.Aliases), &service.Category, &service.DefaultPrice, &service.Currency, &service.CreatedAt,
+		&service.UpdatedAt, &service.TenantID)
+	return service, err
+}
+
+// Checking if the error is caused by violation of the constraint
+func violates(err error, code string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == pq.ErrorCode(code)
+}
+
+// CreateService inserts new service into the catalog and returns its id, if the insertion was successful, or returns id of the service already
+// using any of its names. The subscriptions named by any of the names are linked to the service, ids of the linked subscriptions are returned
+func (db *Database) CreateService(ctx context.Context, service models.CatalogService) (models.IDResponse, []int, error) {
+	var res models.IDResponse
+	var linked []int
+	err := db.transaction(ctx, func(tx *sql.Tx) error {
+		// Checking if the names are already used
+		id, err := serviceUsingNames(ctx, tx, service)
+		if err != nil {
+			return err
+		} else if id != 0 {
+			res.ID = id
+			return models.NewErrConflict()
+		}
+
+		// Inserting the service
+		query := `INSERT INTO services (name, aliases, category, default_price, currency, created_at, updated_at, tenant_id) VALUES ($1, $2, $3, $4, $5, $6,
+			$6, $7) RETURNING id;`
+		err = tx.QueryRowContext(ctx, query, service.Name, pq.Array(service.Aliases), service.Category, service.DefaultPrice, service.Currency,
+			time.Now(), models.TenantFromContext(ctx)).Scan(&service.ID)
+		if err != nil {
+			return models.NewErrInternalServer(err)
+		}
+		res.ID = service.ID
+		if err = storeServiceNames(ctx, tx, service); err != nil {
+			return err
+		}
+		linked, err = linkSubscriptions(ctx, tx, service)
+		return err
+	})
+	return res, linked, err
+}
+
+// ReadService returns the service of the tenant's catalog specified by its id
+func (db *Database) ReadService(ctx context.Context, id int) (models.CatalogService, error) {
+	query := `SELECT ` + serviceColumns + ` FROM services WHERE id = $1 AND tenant_id = $2;`
+	service, err := scanService(db.QueryRowContext(ctx, query, id, models.TenantFromContext(ctx)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.CatalogService{}, models.NewErrNotFound()
+	} else if err != nil {
+		return models.CatalogService{}, models.NewErrInternalServer(err)
+	}
+	return service, nil
+}
+
+// ResolveService returns the service of the tenant's catalog which name or alias matches the name ignoring case and spaces
+func (db *Database) ResolveService(ctx context.Context, name string) (models.CatalogService, error) {
+	query := `SELECT ` + serviceColumns + ` FROM services WHERE id = (SELECT service_id FROM service_names WHERE tenant_id = $2 AND name = $1);`
+	service, err := scanService(db.QueryRowContext(ctx, query, models.NormalizeServiceName(name), models.TenantFromContext(ctx)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.CatalogService{}, models.NewErrNotFound()
+	} else if err != nil {
+		return models.CatalogService{}, models.NewErrInternalServer(err)
+	}
+	return service, nil
+}
+
+// UpdateService updates the service of the tenant's catalog specified by its id, links the subscriptions named by its new names and renames the
+// subscriptions referencing it. The names used by another service conflict. Ids of the linked and renamed subscriptions are returned
+func (db *Database) UpdateService(ctx context.Context, service models.CatalogService) ([]int, error) {
+	var renamed []int
+	err := db.transaction(ctx, func(tx *sql.Tx) error {
+		// Checking if the names are already used
+		id, err := serviceUsingNames(ctx, tx, service)
+		if err != nil {
+			return err
+		} else if id != 0 && id != service.ID {
+			return models.NewErrConflict()
+		}
+
+		// Updating the service
+		query := `UPDATE services SET name = $2, aliases = $3, category = $4, default_price = $5, currency = $6, updated_at = $7 WHERE id = $1
+			AND tenant_id = $8;`
+		res, err := tx.ExecContext(ctx, query, service.ID, service.Name, pq.Array(service.Aliases), service.Category, service.DefaultPrice, service.Currency,
+			time.Now(), models.TenantFromContext(ctx))
+		if err != nil {
+			return models.NewErrInternalServer(err)
+		}
+		if rows, err := res.RowsAffected(); err != nil {
+			return models.NewErrInternalServer(err)
+		} else if rows == 0 {
+			return models.NewErrNotFound()
+		}
+		if err = storeServiceNames(ctx, tx, service); err != nil {
+			return err
+		}
+		if renamed, err = linkSubscriptions(ctx, tx, service); err != nil {
+			return err
+		}
+
+		// Renaming the subscriptions, the rows are being read before they are updated
+		rows, err := tx.QueryContext(ctx, `SELECT `+subscriptionColumns+` FROM subscriptions WHERE service_id = $1 AND service_name <> $2
+			AND deleted_at IS NULL ORDER BY id FOR UPDATE;`, service.ID, service.Name)
+		if err != nil {
+			return models.NewErrInternalServer(err)
+		}
+		var subscriptions []models.Subscription
+		for rows.Next() {
+			subscription, err := scanSubscription(rows)
+			if err != nil {
+				rows.Close()
+				return models.NewErrInternalServer(err)
+			}
+			subscriptions = append(subscriptions, subscription)
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return models.NewErrInternalServer(err)
+		}
+		for _, subscription := range subscriptions {
+			subscription.ServiceName = service.Name
+			if err = update(ctx, tx, subscription); err != nil {
+				return err
+			}
+			renamed = append(renamed, subscription.ID)
+		}
+		return nil
+	})
+	return renamed, err
+}
+
+// DeleteService deletes the service from the tenant's catalog. The service referenced by any subscription including deleted ones can't be deleted
+func (db *Database) DeleteService(ctx context.Context, id int) error {
+	res, err := db.ExecContext(ctx, `DELETE FROM services WHERE id = $1 AND tenant_id = $2;`, id, models.TenantFromContext(ctx))
+	if violates(err, foreignKeyViolation) {
+		return models.NewErrConflict()
+	} else if err != nil {
+		return models.NewErrInternalServer(err)
+	}
+	if rows, err := res.RowsAffected(); err != nil {
+		return models.NewErrInternalServer(err)
+	} else if rows == 0 {
+		return models.NewErrNotFound()
+	}
+	return nil
+}
+
+// ListServices returns the services of the tenant's catalog ordered by name. The services can be filtered by category
+func (db *Database) ListServices(ctx context.Context, category string) ([]models.CatalogService, error) {
+	query := `SELECT ` + serviceColumns + ` FROM services WHERE tenant_id = $2 AND ($1 = '' OR category = $1) ORDER BY name, id;`
+	rows, err := db.QueryContext(ctx, query, category, models.TenantFromContext(ctx))
+	if err != nil {
+		return nil, models.NewErrInternalServer(err)
+	}
+	defer rows.Close()
+
+	services := []models.CatalogService{}
+	for rows.Next() {
+		service, err := scanService(rows)
+		if err != nil {
+			return nil, models.NewErrInternalServer(err)
+		}
+		services = append(services, service)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, models.NewErrInternalServer(err)
+	}
+	return services, nil
+}
+
+// Getting id of the tenant's service using any of the names of the service, the other services go first. Zero is returned if the names are free
+func serviceUsingNames(ctx context.Context, q querier, service models.CatalogService) (int, error) {
+	query := `SELECT service_id FROM service_names WHERE tenant_id = $3 AND name = ANY($1) ORDER BY service_id = $2 LIMIT 1;`
+	var id int
+	err := q.QueryRowContext(ctx, query, pq.Array(service.Names()), service.ID, models.TenantFromContext(ctx)).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	} else if err != nil {
+		return 0, models.NewErrInternalServer(err)
+	}
+	return id, nil
+}
+
+// Storing the normalized names of the service the subscriptions are being resolved by
+func storeServiceNames(ctx context.Context, q querier, service models.CatalogService) error {
+	if _, err := q.ExecContext(ctx, `DELETE FROM service_names WHERE service_id = $1;`, service.ID); err != nil {
+		return models.NewErrInternalServer(err)
+	}
+	for _, name := range service.Names() {
+		_, err := q.ExecContext(ctx, `INSERT INTO service_names (name, service_id, tenant_id) VALUES ($1, $2, $3);`, name, service.ID,
+			models.TenantFromContext(ctx))
+		if violates(err, uniqueViolation) {
+			return models.NewErrConflict()
+		} else if err != nil {
+			return models.NewErrInternalServer(err)
+		}
+	}
+	return nil
+}
+
+// Linking the tenant's subscriptions which don't reference any service and are named by any of the names of the service ignoring case and spaces.
+// The subscriptions are renamed to the canonical name, the ones conflicting with the subscription of the same user already named so are kept as
+// they are. Ids of the linked subscriptions are returned
+func linkSubscriptions(ctx context.Context, tx *sql.Tx, service models.CatalogService) ([]int, error) {
+	query := `SELECT ` + subscriptionColumns + ` FROM subscriptions WHERE tenant_id = $1 AND service_id IS NULL AND deleted_at IS NULL
+		AND lower(btrim(regexp_replace(service_name, '\s+', ' ', 'g'))) = ANY($2) ORDER BY id FOR UPDATE;`
+	rows, err := tx.QueryContext(ctx, query, models.TenantFromContext(ctx), pq.Array(service.Names()))
+	if err != nil {
+		return nil, models.NewErrInternalServer(err)
+	}
+	var subscriptions []models.Subscription
+	for rows.Next() {
+		subscription, err := scanSubscription(rows)
+		if err != nil {
+			rows.Close()
+			return nil, models.NewErrInternalServer(err)
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, models.NewErrInternalServer(err)
+	}
+
+	var linked []int
+	for _, subscription := range subscriptions {
+		subscription.ServiceName, subscription.ServiceID = service.Name, service.ID
+		if err = update(ctx, tx, subscription); errors.Is(err, models.ErrConflict) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		linked = append(linked, subscription.ID)
+	}
+	return linked, nil
+}
@@ -0,0 +1,36 @@
+package database
+
+import (
+	"context"
+	"slices"
+
+	"github.com/middelmatigheid/subscriptions-api/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// Replacing the tags of the subscription, missing tags are being created. The tags are returned ordered by name
+func storeTags(ctx context.Context, q querier, id int, tags []string) ([]string, error) {
+	if _, err := q.ExecContext(ctx, `DELETE FROM subscription_tags WHERE subscription_id = $1;`, id); err != nil {
+		return nil, models.NewErrInternalServer(err)
+	}
+	if len(tags) == 0 {
+		return []string{}, nil
+	}
+	if _, err := q.ExecContext(ctx, `INSERT INTO tags (name) SELECT unnest($1::text[]) ON CONFLICT (name) DO NOTHING;`, pq.Array(tags)); err != nil {
+		return nil, models.NewErrInternalServer(err)
+	}
+	query := `INSERT INTO subscription_tags (subscription_id, tag_id) SELECT $1, id FROM tags WHERE name = ANY($2);`
+	if _, err := q.ExecContext(ctx, query, id, pq.Array(tags)); err != nil {
+		return nil, models.NewErrInternalServer(err)
+	}
+	tags = slices.Clone(tags)
+	slices.Sort(tags)
+	return tags, nil
+}
+
+// Building condition for the subscriptions having all the tags
+func filterTags(tags []string, args *arguments) string {
+	return `id IN (SELECT subscription_tags.subscription_id FROM subscription_tags JOIN tags ON tags.id = subscription_tags.tag_id
+		WHERE tags.name = ANY(` + args.add(pq.Array(tags)) + `) GROUP BY subscription_tags.subscription_id HAVING COUNT(*) = ` + args.add(len(tags)) + `)`
+}
@@ -0,0 +1,322 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/middelmatigheid/subscriptions-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Columns of the exported file
+var csvColumns = []string{"id", "service_name", "price", "currency", "billing_unit", "billing_interval", "user_uuid", "start_date", "end_date", "category",
+	"tags", "version", "created_at", "updated_at", "deleted_at"}
+
+// Columns of the imported file, the other columns are being ignored
+var csvRequiredColumns = []string{"service_name", "price", "user_uuid", "start_date"}
+var csvOptionalColumns = []string{"currency", "billing_unit", "billing_interval", "end_date", "category", "tags"}
+
+// Tags are being separated by semicolon inside of the column
+const csvTagsSeparator = ";"
+
+// Rows are being flushed to the client in batches
+const csvFlushRows = 100
+
+// Getting row of the exported file
+func csvRecord(subscription models.Subscription) []string {
+	date := func(date models.CustomDate) string {
+		if !date.Valid {
+			return ""
+		}
+		return date.ToString()
+	}
+	timestamp := func(timestamp models.CustomTime) string {
+		if !timestamp.Valid {
+			return ""
+		}
+		return timestamp.ToString()
+	}
+	return []string{strconv.Itoa(subscription.ID), subscription.ServiceName, strconv.Itoa(subscription.Price), subscription.Currency, subscription.BillingUnit,
+		strconv.Itoa(subscription.BillingInterval), subscription.UserUUID.String(), date(subscription.StartDate), date(subscription.EndDate),
+		subscription.Category, strings.Join(subscription.Tags, csvTagsSeparator), strconv.Itoa(subscription.Version), timestamp(subscription.CreatedAt), timestamp(subscription.UpdatedAt), timestamp(subscription.DeletedAt)}
+}
+
+// Normalizing the header of the column, so "Service Name" matches service_name
+func csvHeader(header string) string {
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(strings.TrimSpace(header)))
+}
+
+// Parsing the imported file. The headers are being renamed by the mapping before they are matched with the columns. Lines which can't be parsed are
+// returned with the error
+func parseCSV(reader io.Reader, mapping map[string]string) ([]models.ImportRow, error) {
+	r := csv.NewReader(reader)
+	r.TrimLeadingSpace = true
+	r.FieldsPerRecord = -1
+
+	// Getting positions of the columns
+	headers, err := r.Read()
+	if err == io.EOF {
+		return nil, models.NewErrBadRequest(errors.New("Empty file"))
+	} else if err != nil {
+		return nil, models.NewErrBadRequest(err)
+	}
+	positions := make(map[string]int)
+	for i, header := range headers {
+		header = csvHeader(header)
+		if column, ok := mapping[header]; ok {
+			header = column
+		}
+		positions[header] = i
+	}
+	for _, column := range csvRequiredColumns {
+		if _, ok := positions[column]; !ok {
+			return nil, models.NewErrBadRequest(errors.New("Missing column " + column))
+		}
+	}
+
+	// Parsing the lines
+	rows := []models.ImportRow{}
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, models.NewErrBadRequest(err)
+		}
+		line, _ := r.FieldPos(0)
+		value := func(column string) string {
+			if i, ok := positions[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		subscription, err := parseCSVRecord(value)
+		rows = append(rows, models.ImportRow{Line: line, Subscription: subscription, Err: err})
+	}
+	return rows, nil
+}
+
+// Parsing the subscription from the values of the columns
+func parseCSVRecord(value func(string) string) (models.Subscription, error) {
+	subscription := models.Subscription{ServiceName: value("service_name"), Currency: value("currency"), BillingUnit: value("billing_unit"),
+		Category: value("category")}
+	if tags := value("tags"); tags != "" {
+		subscription.Tags = strings.Split(tags, csvTagsSeparator)
+	}
+	var err error
+	if subscription.Price, err = strconv.Atoi(value("price")); err != nil {
+		return subscription, models.NewErrBadRequest(errors.New("Invalid price"))
+	}
+	if interval := value("billing_interval"); interval != "" {
+		if subscription.BillingInterval, err = strconv.Atoi(interval); err != nil {
+			return subscription, models.NewErrBadRequest(errors.New("Invalid billing interval"))
+		}
+	}
+	if subscription.UserUUID, err = uuid.Parse(value("user_uuid")); err != nil {
+		return subscription, models.NewErrBadRequest(errors.New("Invalid user uuid"))
+	}
+	for _, field := range []struct {
+		column string
+		date   *models.CustomDate
+	}{{"start_date", &subscription.StartDate}, {"end_date", &subscription.EndDate}} {
+		if value(field.column) == "" {
+			continue
+		}
+		date, err := time.Parse("01-2006", value(field.column))
+		if err != nil {
+			return subscription, models.NewErrBadRequest(errors.New("Invalid " + strings.ReplaceAll(field.column, "_", " ")))
+		}
+		*field.date = models.CustomDate{NullTime: sql.NullTime{Time: date, Valid: true}}
+	}
+	return subscription, nil
+}
+
+// @Summary Export subscriptions
+// @Description The endpoint streams all subscriptions filtered the same way as the list without the limit as a file. Only csv format is supported
+// @Tags subscriptions
+// @Produce text/csv
+// @Security ApiKeyAuth
+// @Param format query string false "csv"
+// @Param user_uuid query []string false "60601fee-2bf1-4721-ae6f-7636e79a0cba" collectionFormat(csv)
+// @Param service_name query string false "Yandex Plus"
+// @Param start_date query string false "07-2025"
+// @Param end_date query string false "08-2025"
+// @Param category query string false "music"
+// @Param tag query []string false "streaming" collectionFormat(csv)
+// @Param service_name_match query string false "exact" Enums(exact, prefix, contains)
+// @Param ignore_case query bool false "false"
+// @Param price_min query int false "100"
+// @Param price_max query int false "1000"
+// @Param active_on query string false "07-2025"
+// @Param has_end_date query bool false "true"
+// @Param created_after query string false "2025-07-01T14:00:00Z"
+// @Param updated_after query string false "2025-07-01T14:00:00Z"
+// @Param include_deleted query bool false "false"
+// @Success 200 {file} file
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 429
+// @Failure 500
+// @Router /api/v1/subscriptions/export [get]
+// @Router /subscriptions/export [get]
+func (h *Handler) Export(c *gin.Context) {
+	// Getting query params
+	if format := c.DefaultQuery("format", "csv"); format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid format", "error": "Unsupported format " + format})
+		return
+	}
+	params, ok := queryPeriod(c)
+	if !ok || !queryFilters(c, &params) {
+		return
+	}
+	var err error
+	params.IncludeDeleted, err = strconv.ParseBool(c.DefaultQuery("include_deleted", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid include deleted", "error": err.Error()})
+		return
+	}
+
+	// The file is being started with the first subscription, so the error can be written instead while nothing is streamed
+	writer := csv.NewWriter(c.Writer)
+	started, rows := false, 0
+	start := func() error {
+		started = true
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", `attachment; filename="subscriptions.csv"`)
+		c.Status(http.StatusOK)
+		return writer.Write(csvColumns)
+	}
+
+	// Streaming the subscriptions
+	ctx := c.Request.Context()
+	err = h.Service.Export(ctx, params, func(subscription models.Subscription) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		if err := writer.Write(csvRecord(subscription)); err != nil {
+			return err
+		}
+		if rows++; rows%csvFlushRows == 0 {
+			writer.Flush()
+			c.Writer.Flush()
+		}
+		return writer.Error()
+	})
+	switch {
+	case err != nil && started:
+		// The status is already sent, the client gets the truncated file
+		c.Error(err)
+		return
+	case errors.Is(err, models.ErrBadRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid request", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrInternalServer):
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "An error occured while getting subscriptions info from the database", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"msg": "The action is forbidden for the credential", "error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "Unknown error", "error": err.Error()})
+		return
+	}
+
+	// Writing the file without subscriptions
+	if !started {
+		start()
+	}
+	writer.Flush()
+}
+
+// @Summary Import subscriptions
+// @Description The endpoint creates subscriptions from csv file in one transaction. The columns are being matched by the headers: service_name, price, user_uuid and start_date are required, currency, billing_unit, billing_interval, end_date, category and tags separated by semicolon are optional, the other columns are being ignored. Custom headers can be mapped to the columns. Nothing is imported if any line is invalid or conflicts, the errors are returned with line numbers. In dry-run mode the lines are only being validated and checked for conflicts. Only admins are allowed to import
+// @Tags subscriptions
+// @Accept text/csv
+// @Produce json
+// @Security ApiKeyAuth
+// @Param file body string true "CSV file"
+// @Param dry_run query bool false "false"
+// @Param mapping query []string false "Cost:price,Service:service_name" collectionFormat(csv)
+// @Success 200 {object} models.ImportResult
+// @Success 201 {object} models.ImportResult
+// @Failure 400 {object} models.ImportResult
+// @Failure 401
+// @Failure 403
+// @Failure 409 {object} models.ImportResult
+// @Failure 413
+// @Failure 429
+// @Failure 500
+// @Router /api/v1/subscriptions/import [post]
+// @Router /subscriptions/import [post]
+func (h *Handler) Import(c *gin.Context) {
+	// Getting query params
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid dry run", "error": err.Error()})
+		return
+	}
+	mapping := make(map[string]string)
+	for _, values := range c.QueryArray("mapping") {
+		for _, value := range strings.Split(values, ",") {
+			header, column, ok := strings.Cut(value, ":")
+			column = csvHeader(column)
+			if !ok || !(slices.Contains(csvRequiredColumns, column) || slices.Contains(csvOptionalColumns, column)) {
+				c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid mapping", "error": "Invalid mapping " + value})
+				return
+			}
+			mapping[csvHeader(header)] = column
+		}
+	}
+
+	// Reading the file
+	var tooLarge *http.MaxBytesError
+	rows, err := parseCSV(http.MaxBytesReader(c.Writer, c.Request.Body, h.ImportMaxSize), mapping)
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"msg": "The file is too large", "error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Error while reading request's body", "error": err.Error()})
+		return
+	}
+
+	// Importing the subscriptions
+	ctx := c.Request.Context()
+	res, err := h.Service.Import(ctx, rows, dryRun)
+	switch {
+	case errors.Is(err, models.ErrBadRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid request", "error": err.Error(), "body": res})
+		return
+	case errors.Is(err, models.ErrInternalServer):
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "An error occured while importing subscriptions into the database", "error": err.Error(), "body": res})
+		return
+	case errors.Is(err, models.ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{"msg": "The subscription is already being stored in the database", "error": err.Error(), "body": res})
+		return
+	case errors.Is(err, models.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"msg": "The action is forbidden for the credential", "error": err.Error(), "body": res})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "Unknown error", "error": err.Error(), "body": res})
+		return
+	}
+
+	// Writing response
+	if dryRun {
+		c.JSON(http.StatusOK, gin.H{"msg": "The subscriptions are valid", "body": res})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"msg": "The subscriptions were successfully imported", "body": res})
+}
@@ -4,11 +4,15 @@ import (
 	"database/sql"
 	"errors"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/middelmatigheid/subscriptions-api/internal/cache"
 	"github.com/middelmatigheid/subscriptions-api/internal/config"
 	"github.com/middelmatigheid/subscriptions-api/internal/models"
+	"github.com/middelmatigheid/subscriptions-api/internal/policy"
 	"github.com/middelmatigheid/subscriptions-api/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -17,14 +21,200 @@ import (
 
 type Handler struct {
 	Service models.SubscriptionService
+
+	// Maximum size of the imported file in bytes
+	ImportMaxSize int64
+}
+
+// Creates the handler reaching the service through the role-based policy
+func NewHandler(config *config.Config, db models.Storage, cache cache.Cache) (*Handler, error) {
+	service, err := service.NewService(config, db, cache)
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{Service: policy.NewPolicy(service), ImportMaxSize: int64(config.ImportMaxSize) << 20}, nil
+}
+
+// Getting subscription id from the path. If the id is invalid the error is being written to the response
+func pathID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid id", "error": err.Error()})
+		return 0, false
+	}
+	if id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid id", "error": "Id should be positive"})
+		return 0, false
+	}
+	return id, true
+}
+
+// Getting entity tag of the subscription's version
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// Getting expected version of the subscription from If-Match header. Zero version matching any is returned if the header is not provided or is "*",
+// negative version matching none is returned if the header is not a tag of the subscription's version
+func ifMatch(c *gin.Context) int {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0
+	}
+	version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(header, "W/"), `"`))
+	if err != nil || version <= 0 {
+		return -1
+	}
+	return version
+}
+
+// Checking if If-None-Match header contains the tag
+func ifNoneMatch(c *gin.Context, tag string) bool {
+	for _, value := range strings.Split(c.GetHeader("If-None-Match"), ",") {
+		value = strings.TrimPrefix(strings.TrimSpace(value), "W/")
+		if value == "*" || value == tag {
+			return true
+		}
+	}
+	return false
 }
 
-func NewHandler(config *config.Config, db models.Storage) (*Handler, error) {
-	service, err := service.NewService(config, db)
+// Getting id, user uuid and service name query params specifying the subscription. If the params are invalid the error is being written to the response
+func queryIdentifier(c *gin.Context) (models.SubscriptionIdentifier, bool) {
+	id, err := strconv.Atoi(c.DefaultQuery("id", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid id", "error": err.Error()})
+		return models.SubscriptionIdentifier{}, false
+	}
+	userUUID, err := uuid.Parse(c.DefaultQuery("user_uuid", "00000000-0000-0000-0000-000000000000"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid user uuid", "error": err.Error()})
+		return models.SubscriptionIdentifier{}, false
+	}
+	serviceName := c.DefaultQuery("service_name", "")
+
+	return models.SubscriptionIdentifier{ID: id, UserUUID: userUUID, ServiceName: serviceName}, true
+}
+
+// Getting user uuid, service name, start date and end date query params filtering the subscriptions. If the params are invalid the error is being
+// written to the response
+func queryPeriod(c *gin.Context) (models.SubscriptionsWithinPeriod, bool) {
+	// Getting user uuids, the uuids can be separated by commas
+	var userUUIDs []uuid.UUID
+	for _, values := range c.QueryArray("user_uuid") {
+		for _, value := range strings.Split(values, ",") {
+			userUUID, err := uuid.Parse(strings.TrimSpace(value))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid user uuid", "error": err.Error()})
+				return models.SubscriptionsWithinPeriod{}, false
+			}
+			if userUUID != uuid.Nil && !slices.Contains(userUUIDs, userUUID) {
+				userUUIDs = append(userUUIDs, userUUID)
+			}
+		}
+	}
+	serviceName := c.DefaultQuery("service_name", "")
+	// Getting start date
+	start := c.DefaultQuery("start_date", "")
+	var startDate models.CustomDate
+	if len(start) > 0 {
+		date, err := time.Parse("01-2006", start)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid time bounds", "error": err.Error()})
+			return models.SubscriptionsWithinPeriod{}, false
+		}
+		startDate = models.CustomDate{NullTime: sql.NullTime{Time: date, Valid: true}}
+	}
+	// Getting end date
+	end := c.DefaultQuery("end_date", "")
+	var endDate models.CustomDate
+	if len(end) > 0 {
+		date, err := time.Parse("01-2006", end)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid time bounds", "error": err.Error()})
+			return models.SubscriptionsWithinPeriod{}, false
+		}
+		endDate = models.CustomDate{NullTime: sql.NullTime{Time: date, Valid: true}}
+	}
+
+	// Getting category and tags, the tags can be separated by commas
+	category := strings.TrimSpace(c.Query("category"))
+	var tags []string
+	for _, values := range c.QueryArray("tag") {
+		for _, tag := range strings.Split(values, ",") {
+			if tag = strings.ToLower(strings.Join(strings.Fields(tag), " ")); tag != "" && !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	return models.SubscriptionsWithinPeriod{UserUUIDs: userUUIDs, ServiceName: serviceName, Category: category, Tags: tags, StartDate: startDate, EndDate: endDate}, true
+}
+
+// Getting filters of the list from query params
+func queryFilters(c *gin.Context, params *models.SubscriptionsWithinPeriod) bool {
+	// Getting match of the service name
+	params.ServiceNameMatch = c.DefaultQuery("service_name_match", models.MatchExact)
+	if !slices.Contains([]string{models.MatchExact, models.MatchPrefix, models.MatchContains}, params.ServiceNameMatch) {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid service name match", "error": "Unsupported match " + params.ServiceNameMatch})
+		return false
+	}
+	var err error
+	params.IgnoreCase, err = strconv.ParseBool(c.DefaultQuery("ignore_case", "false"))
 	if err != nil {
-		return nil, nil
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid ignore case", "error": err.Error()})
+		return false
 	}
-	return &Handler{Service: service}, nil
+	// Getting price range
+	for _, field := range []struct {
+		param string
+		price *int
+	}{{"price_min", &params.PriceMin}, {"price_max", &params.PriceMax}} {
+		if value := c.Query(field.param); value != "" {
+			if *field.price, err = strconv.Atoi(value); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid " + strings.ReplaceAll(field.param, "_", " "), "error": err.Error()})
+				return false
+			}
+		}
+	}
+	// Getting month the subscriptions are active on
+	if value := c.Query("active_on"); value != "" {
+		date, err := time.Parse("01-2006", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid active on", "error": err.Error()})
+			return false
+		}
+		params.ActiveOn = models.CustomDate{NullTime: sql.NullTime{Time: date, Valid: true}}
+	}
+	// Getting presence of the end date
+	if value := c.Query("has_end_date"); value != "" {
+		hasEndDate, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid has end date", "error": err.Error()})
+			return false
+		}
+		params.HasEndDate = &hasEndDate
+	}
+	// Getting timestamps, both RFC 3339 and the format of the responses are accepted
+	for _, field := range []struct {
+		param     string
+		timestamp *models.CustomTime
+	}{{"created_after", &params.CreatedAfter}, {"updated_after", &params.UpdatedAfter}} {
+		value := c.Query(field.param)
+		if value == "" {
+			continue
+		}
+		timestamp, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			timestamp, err = time.Parse("02-01-2006 15:04", value)
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid " + strings.ReplaceAll(field.param, "_", " "), "error": err.Error()})
+			return false
+		}
+		*field.timestamp = models.CustomTime{NullTime: sql.NullTime{Time: timestamp, Valid: true}}
+	}
+	return true
 }
 
 // @Summary Create a new subscription
@@ -32,12 +222,17 @@ func NewHandler(config *config.Config, db models.Storage) (*Handler, error) {
 // @Tags subscriptions
 // @Accept json
 // @Produce json
+// @Security ApiKeyAuth
 // @Param subscription body models.Subscription true "Subscription data"
 // @Success 201 {object} models.IDResponse
-// @Success 409
 // @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 409 {object} models.IDResponse
+// @Failure 429
 // @Failure 500
-// @Router /create [post]
+// @Router /api/v1/subscriptions [post]
+// @Router /subscriptions/create [post]
 func (h *Handler) Create(c *gin.Context) {
 	// Reading request's body
 	var subscription models.Subscription
@@ -59,45 +254,78 @@ func (h *Handler) Create(c *gin.Context) {
 	case errors.Is(err, models.ErrConflict):
 		c.JSON(http.StatusConflict, gin.H{"msg": "The subscription is already being stored in the database", "error": err.Error(), "body": res})
 		return
+	case errors.Is(err, models.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"msg": "The action is forbidden for the credential", "error": err.Error()})
+		return
 	case err != nil:
 		c.JSON(http.StatusInternalServerError, gin.H{"msg": "Unknown error", "error": err.Error()})
 		return
 	}
 
 	// Writing response
+	c.Header("Location", "/api/v1/subscriptions/"+strconv.Itoa(res.ID))
 	c.JSON(http.StatusCreated, gin.H{"msg": "The subscription successfully created", "body": res})
 }
 
 // @Summary Get subscription information
-// @Description The endpoints return subscription's info. The subscription is being specified by its id or combination of user uuid and service name
+// @Description The endpoints return subscription's info. The subscription is being specified by its id or combination of user uuid and service name. Deprecated, use GET /api/v1/subscriptions/{id}
 // @Tags subscriptions
 // @Accept json
 // @Produce json
+// @Security ApiKeyAuth
 // @Param id query int false "1"
 // @Param user_uuid query string false "60601fee-2bf1-4721-ae6f-7636e79a0cba"
 // @Param service_name query string false "Yandex Plus"
+// @Param If-None-Match header string false "\"1\""
 // @Success 200 {object} models.Subscription
+// @Success 304
 // @Failure 400
+// @Failure 401
+// @Failure 403
 // @Failure 404
+// @Failure 429
 // @Failure 500
-// @Router /read [get]
+// @Deprecated
+// @Router /subscriptions/read [get]
 func (h *Handler) Read(c *gin.Context) {
 	// Getting query params
-	id, err := strconv.Atoi(c.DefaultQuery("id", "0"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid id", "error": err.Error()})
+	identifier, ok := queryIdentifier(c)
+	if !ok {
 		return
 	}
-	userUUID, err := uuid.Parse(c.DefaultQuery("user_uuid", "00000000-0000-0000-0000-000000000000"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid user uuid", "error": err.Error()})
+	h.read(c, identifier)
+}
+
+// @Summary Get subscription information
+// @Description The endpoints return subscription's info. The subscription is being specified by its id
+// @Tags subscriptions
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "1"
+// @Param If-None-Match header string false "\"1\""
+// @Success 200 {object} models.Subscription
+// @Success 304
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 404
+// @Failure 429
+// @Failure 500
+// @Router /api/v1/subscriptions/{id} [get]
+func (h *Handler) ReadByID(c *gin.Context) {
+	// Getting path params
+	id, ok := pathID(c)
+	if !ok {
 		return
 	}
-	serviceName := c.DefaultQuery("service_name", "")
+	h.read(c, models.SubscriptionIdentifier{ID: id})
+}
 
+// Reading the subscription and writing it to the response
+func (h *Handler) read(c *gin.Context, identifier models.SubscriptionIdentifier) {
 	// Getting subscription's info from the database
 	ctx := c.Request.Context()
-	res, err := h.Service.Read(ctx, models.SubscriptionIdentifier{ID: id, UserUUID: userUUID, ServiceName: serviceName})
+	res, err := h.Service.Read(ctx, identifier)
 	switch {
 	case errors.Is(err, models.ErrBadRequest):
 		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid request", "error": err.Error()})
@@ -108,26 +336,43 @@ func (h *Handler) Read(c *gin.Context) {
 	case errors.Is(err, models.ErrNotFound):
 		c.JSON(http.StatusNotFound, gin.H{"msg": "The subscription is not found in the database", "error": err.Error()})
 		return
+	case errors.Is(err, models.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"msg": "The action is forbidden for the credential", "error": err.Error()})
+		return
 	case err != nil:
 		c.JSON(http.StatusInternalServerError, gin.H{"msg": "Unknown error", "error": err.Error()})
 		return
 	}
 
-	// Writing response
+	// Writing response, the subscription isn't sent if the client has the same version
+	tag := etag(res.Version)
+	c.Header("ETag", tag)
+	if ifNoneMatch(c, tag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"msg": "The subscrtiption was successfully read", "body": res})
 }
 
 // @Summary Update subscription
-// @Description The endpoint updates existing subscription's info. The subscription is being specified by its id. All fields should be provided. If another subscription with the same user uuid and service name already exists in the database a conflict error will be thrown
+// @Description The endpoint updates existing subscription's info. The subscription is being specified by its id. All fields should be provided. If another subscription with the same user uuid and service name already exists in the database a conflict error will be thrown. Deprecated, use PUT /api/v1/subscriptions/{id}
 // @Tags subscriptions
 // @Accept json
 // @Produce json
+// @Security ApiKeyAuth
+// @Param If-Match header string false "\"1\""
 // @Param subscription body models.Subscription true "Updated subscription data"
 // @Success 200
 // @Failure 400
+// @Failure 401
+// @Failure 403
 // @Failure 404
+// @Failure 409
+// @Failure 412
+// @Failure 429
 // @Failure 500
-// @Router /update [put]
+// @Deprecated
+// @Router /subscriptions/update [put]
 func (h *Handler) Update(c *gin.Context) {
 	// Readind request's body
 	var subscription models.Subscription
@@ -135,8 +380,53 @@ func (h *Handler) Update(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"msg": "Error while reading request's body", "error": err.Error()})
 		return
 	}
+	h.update(c, subscription)
+}
 
+// @Summary Update subscription
+// @Description The endpoint updates existing subscription's info. The subscription is being specified by its id. All fields should be provided. If another subscription with the same user uuid and service name already exists in the database a conflict error will be thrown
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "1"
+// @Param If-Match header string false "\"1\""
+// @Param subscription body models.Subscription true "Updated subscription data"
+// @Success 200
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 404
+// @Failure 409
+// @Failure 412
+// @Failure 429
+// @Failure 500
+// @Router /api/v1/subscriptions/{id} [put]
+func (h *Handler) UpdateByID(c *gin.Context) {
+	// Getting path params
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	// Readind request's body
+	var subscription models.Subscription
+	if err := c.ShouldBindJSON(&subscription); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Error while reading request's body", "error": err.Error()})
+		return
+	}
+	if subscription.ID != 0 && subscription.ID != id {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid request", "error": "Id in the path and in the body differ"})
+		return
+	}
+	subscription.ID = id
+	h.update(c, subscription)
+}
+
+// Updating the subscription and writing the result to the response. The subscription is updated only if its version matches If-Match header
+func (h *Handler) update(c *gin.Context, subscription models.Subscription) {
 	// Updating the subscription's info
+	subscription.Version = ifMatch(c)
 	ctx := c.Request.Context()
 	err := h.Service.Update(ctx, subscription)
 	switch {
@@ -152,6 +442,12 @@ func (h *Handler) Update(c *gin.Context) {
 	case errors.Is(err, models.ErrConflict):
 		c.JSON(http.StatusConflict, gin.H{"msg": "The subscription is already being stored in the database", "error": err.Error()})
 		return
+	case errors.Is(err, models.ErrPrecondition):
+		c.JSON(http.StatusPreconditionFailed, gin.H{"msg": "The subscription was changed by someone else", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"msg": "The action is forbidden for the credential", "error": err.Error()})
+		return
 	case err != nil:
 		c.JSON(http.StatusInternalServerError, gin.H{"msg": "Unknown error", "error": err.Error()})
 		return
@@ -162,16 +458,24 @@ func (h *Handler) Update(c *gin.Context) {
 }
 
 // @Summary Partial subscription update
-// @Description The endpoints updates existing subscription's info partially. The subscription is being specified by its id. If another subscription with the same user uuid and service name already exists in the database a conflict error will be thrown. Only updating fields can be specified, other fields will remain the same
+// @Description The endpoints updates existing subscription's info partially. The subscription is being specified by its id. If another subscription with the same user uuid and service name already exists in the database a conflict error will be thrown. Only updating fields can be specified, other fields will remain the same. Deprecated, use PATCH /api/v1/subscriptions/{id}
 // @Tags subscriptions
 // @Accept json
 // @Produce json
+// @Security ApiKeyAuth
+// @Param If-Match header string false "\"1\""
 // @Param subscription body models.SubscriptionPatch true "Updated subscription data"
 // @Success 200
 // @Failure 400
+// @Failure 401
+// @Failure 403
 // @Failure 404
+// @Failure 409
+// @Failure 412
+// @Failure 429
 // @Failure 500
-// @Router /patch [put]
+// @Deprecated
+// @Router /subscriptions/patch [put]
 func (h *Handler) Patch(c *gin.Context) {
 	// Readind request's body
 	var subscriptionPatch models.SubscriptionPatch
@@ -179,8 +483,53 @@ func (h *Handler) Patch(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"msg": "Error while reading request's body", "error": err.Error()})
 		return
 	}
+	h.patch(c, subscriptionPatch)
+}
+
+// @Summary Partial subscription update
+// @Description The endpoints updates existing subscription's info partially. The subscription is being specified by its id. If another subscription with the same user uuid and service name already exists in the database a conflict error will be thrown. Only updating fields can be specified, other fields will remain the same
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "1"
+// @Param If-Match header string false "\"1\""
+// @Param subscription body models.SubscriptionPatch true "Updated subscription data"
+// @Success 200
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 404
+// @Failure 409
+// @Failure 412
+// @Failure 429
+// @Failure 500
+// @Router /api/v1/subscriptions/{id} [patch]
+func (h *Handler) PatchByID(c *gin.Context) {
+	// Getting path params
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	// Readind request's body
+	var subscriptionPatch models.SubscriptionPatch
+	if err := c.ShouldBindJSON(&subscriptionPatch); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Error while reading request's body", "error": err.Error()})
+		return
+	}
+	if subscriptionPatch.ID != 0 && subscriptionPatch.ID != id {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid request", "error": "Id in the path and in the body differ"})
+		return
+	}
+	subscriptionPatch.ID = id
+	h.patch(c, subscriptionPatch)
+}
 
+// Updating the subscription partially and writing the result to the response. The subscription is updated only if its version matches If-Match header
+func (h *Handler) patch(c *gin.Context, subscriptionPatch models.SubscriptionPatch) {
 	// Updating the subscription's info
+	subscriptionPatch.Version = ifMatch(c)
 	ctx := c.Request.Context()
 	err := h.Service.Patch(ctx, subscriptionPatch)
 	switch {
@@ -193,6 +542,15 @@ func (h *Handler) Patch(c *gin.Context) {
 	case errors.Is(err, models.ErrNotFound):
 		c.JSON(http.StatusNotFound, gin.H{"msg": "The subscription is not found in the database", "error": err.Error()})
 		return
+	case errors.Is(err, models.ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{"msg": "The subscription is already being stored in the database", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrPrecondition):
+		c.JSON(http.StatusPreconditionFailed, gin.H{"msg": "The subscription was changed by someone else", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"msg": "The action is forbidden for the credential", "error": err.Error()})
+		return
 	case err != nil:
 		c.JSON(http.StatusInternalServerError, gin.H{"msg": "Unknown error", "error": err.Error()})
 		return
@@ -203,119 +561,503 @@ func (h *Handler) Patch(c *gin.Context) {
 }
 
 // @Summary Delete subscription
-// @Description The endpoint deletes subscription from the database. The subscription is being specified by its id or combination of user uuid and service name
+// @Description The endpoint moves subscription to the trash. The subscription is being specified by its id or combination of user uuid and service name. Deprecated, use DELETE /api/v1/subscriptions/{id}
 // @Tags subscriptions
 // @Produce json
+// @Security ApiKeyAuth
 // @Param id query int false "1"
 // @Param user_uuid query string false "60601fee-2bf1-4721-ae6f-7636e79a0cba"
 // @Param service_name query string false "Yandex Plus"
+// @Param If-Match header string false "\"1\""
 // @Success 200
 // @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 404
+// @Failure 412
+// @Failure 429
 // @Failure 500
-// @Router /delete [delete]
+// @Deprecated
+// @Router /subscriptions/delete [delete]
 func (h *Handler) Delete(c *gin.Context) {
 	// Getting query params
-	id, err := strconv.Atoi(c.DefaultQuery("id", "0"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid id", "error": err.Error()})
+	identifier, ok := queryIdentifier(c)
+	if !ok {
 		return
 	}
-	userUUID, err := uuid.Parse(c.DefaultQuery("user_uuid", "00000000-0000-0000-0000-000000000000"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid user uuid", "error": err.Error()})
+
+	// Deleting the subscription from the database
+	if h.delete(c, identifier) {
+		c.JSON(http.StatusOK, gin.H{"msg": "The subscription was successfully deleted"})
+	}
+}
+
+// @Summary Delete subscription
+// @Description The endpoint moves subscription to the trash. The subscription is being specified by its id, it can be restored until it is purged after the retention period
+// @Tags subscriptions
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "1"
+// @Param If-Match header string false "\"1\""
+// @Success 204
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 404
+// @Failure 412
+// @Failure 429
+// @Failure 500
+// @Router /api/v1/subscriptions/{id} [delete]
+func (h *Handler) DeleteByID(c *gin.Context) {
+	// Getting path params
+	id, ok := pathID(c)
+	if !ok {
 		return
 	}
-	serviceName := c.DefaultQuery("service_name", "")
 
 	// Deleting the subscription from the database
+	if h.delete(c, models.SubscriptionIdentifier{ID: id}) {
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// Deleting the subscription. The subscription is deleted only if its version matches If-Match header. If the subscription wasn't deleted the error
+// is being written to the response
+func (h *Handler) delete(c *gin.Context, identifier models.SubscriptionIdentifier) bool {
+	identifier.Version = ifMatch(c)
 	ctx := c.Request.Context()
-	err = h.Service.Delete(ctx, models.SubscriptionIdentifier{ID: id, UserUUID: userUUID, ServiceName: serviceName})
+	err := h.Service.Delete(ctx, identifier)
 	switch {
 	case errors.Is(err, models.ErrBadRequest):
 		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid request", "error": err.Error()})
-		return
+		return false
 	case errors.Is(err, models.ErrInternalServer):
 		c.JSON(http.StatusInternalServerError, gin.H{"msg": "An error occured while deleting subscription info from the database", "error": err.Error()})
+		return false
+	case errors.Is(err, models.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"msg": "The subscription is not found in the database", "error": err.Error()})
+		return false
+	case errors.Is(err, models.ErrPrecondition):
+		c.JSON(http.StatusPreconditionFailed, gin.H{"msg": "The subscription was changed by someone else", "error": err.Error()})
+		return false
+	case errors.Is(err, models.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"msg": "The action is forbidden for the credential", "error": err.Error()})
+		return false
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "Unknown error", "error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// @Summary Get subscription history
+// @Description The endpoint returns changes of the subscription in order they were made. Each change contains the subscription's state before and after it, the authenticated client who made it, the label the client gave itself in X-Actor header and id of the request, so the subscription's state can be reconstructed at any point in time. Scheduled prices are recorded as schedule_price changes carrying the price
+// @Tags subscriptions
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "1"
+// @Success 200 {array} models.SubscriptionEvent
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 404
+// @Failure 429
+// @Failure 500
+// @Router /api/v1/subscriptions/{id}/history [get]
+func (h *Handler) History(c *gin.Context) {
+	// Getting path params
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	// Getting changes of the subscription from the database
+	ctx := c.Request.Context()
+	res, err := h.Service.History(ctx, id)
+	switch {
+	case errors.Is(err, models.ErrBadRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid request", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrInternalServer):
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "An error occured while getting subscription history from the database", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"msg": "The subscription history is not found in the database", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"msg": "The action is forbidden for the credential", "error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "Unknown error", "error": err.Error()})
+		return
+	}
+
+	// Writing response
+	c.JSON(http.StatusOK, gin.H{"msg": "The subscription history was successfully read", "body": res})
+}
+
+// @Summary Schedule subscription price change
+// @Description The endpoint sets the price of the subscription effective from the month until the next change, the price already scheduled for the month is being replaced. The month should be after the subscription's start date and not after its end date. The summary charges the price effective at each billing date. The scheduled price is recorded in the subscription's history
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "1"
+// @Param change body models.PriceChange true "Price change"
+// @Success 201
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 404
+// @Failure 429
+// @Failure 500
+// @Router /api/v1/subscriptions/{id}/prices [post]
+func (h *Handler) SchedulePrice(c *gin.Context) {
+	// Getting path params
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	// Reading request's body
+	var change models.PriceChange
+	if err := c.ShouldBindJSON(&change); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Error while reading request's body", "error": err.Error()})
+		return
+	}
+
+	// Scheduling the price change
+	ctx := c.Request.Context()
+	err := h.Service.SchedulePrice(ctx, id, change)
+	switch {
+	case errors.Is(err, models.ErrBadRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid request", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrInternalServer):
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "An error occured while scheduling price change in the database", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"msg": "The subscription is not found in the database", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"msg": "The action is forbidden for the credential", "error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "Unknown error", "error": err.Error()})
+		return
+	}
+
+	// Writing response
+	c.Header("Location", "/api/v1/subscriptions/"+strconv.Itoa(id)+"/prices")
+	c.JSON(http.StatusCreated, gin.H{"msg": "The price change was successfully scheduled"})
+}
+
+// @Summary Get subscription price history
+// @Description The endpoint returns prices of the subscription ordered by the month they are effective from. The first price is the subscription's own price effective from its start date
+// @Tags subscriptions
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "1"
+// @Success 200 {array} models.PriceChange
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 404
+// @Failure 429
+// @Failure 500
+// @Router /api/v1/subscriptions/{id}/prices [get]
+func (h *Handler) Prices(c *gin.Context) {
+	// Getting path params
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	// Getting prices of the subscription from the database
+	ctx := c.Request.Context()
+	res, err := h.Service.Prices(ctx, id)
+	switch {
+	case errors.Is(err, models.ErrBadRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid request", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrInternalServer):
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "An error occured while getting subscription prices from the database", "error": err.Error()})
 		return
 	case errors.Is(err, models.ErrNotFound):
 		c.JSON(http.StatusNotFound, gin.H{"msg": "The subscription is not found in the database", "error": err.Error()})
 		return
+	case errors.Is(err, models.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"msg": "The action is forbidden for the credential", "error": err.Error()})
+		return
 	case err != nil:
 		c.JSON(http.StatusInternalServerError, gin.H{"msg": "Unknown error", "error": err.Error()})
 		return
 	}
 
 	// Writing response
-	c.JSON(http.StatusOK, gin.H{"msg": "The subscription was successfully deleted"})
+	c.JSON(http.StatusOK, gin.H{"msg": "The subscription prices were successfully read", "body": res})
+}
+
+// @Summary Bulk create, update and delete subscriptions
+// @Description The endpoint executes an array of create, update and delete operations. In all_or_nothing mode the operations are executed in one transaction and a failure of any operation aborts the other ones with 424, in best_effort mode each operation is executed independently. The status of each operation is returned the same way as by the single endpoints. The response is 200 if every operation succeeded and 207 otherwise. Only admins are allowed the bulk operations
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body models.BulkRequest true "Operations"
+// @Success 200 {array} models.BulkResult
+// @Success 207 {array} models.BulkResult
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 429
+// @Failure 500
+// @Router /api/v1/subscriptions/bulk [post]
+func (h *Handler) Bulk(c *gin.Context) {
+	// Reading request's body
+	var request models.BulkRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Error while reading request's body", "error": err.Error()})
+		return
+	}
+
+	// Executing the operations
+	ctx := c.Request.Context()
+	res, err := h.Service.Bulk(ctx, request)
+	switch {
+	case errors.Is(err, models.ErrBadRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid request", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrInternalServer):
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "An error occured while executing operations in the database", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"msg": "The action is forbidden for the credential", "error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "Unknown error", "error": err.Error()})
+		return
+	}
+
+	// Writing status of each operation
+	status := http.StatusOK
+	for i := range res {
+		res[i].Status, res[i].Msg = bulkStatus(res[i])
+		if res[i].Err != nil {
+			res[i].Error = res[i].Err.Error()
+			status = http.StatusMultiStatus
+		}
+	}
+	c.JSON(status, gin.H{"msg": "The operations were executed", "body": res})
+}
+
+// Getting status and message of the bulk operation the same way the single endpoints respond
+func bulkStatus(result models.BulkResult) (int, string) {
+	switch {
+	case errors.Is(result.Err, models.ErrBadRequest):
+		return http.StatusBadRequest, "Invalid request"
+	case errors.Is(result.Err, models.ErrInternalServer):
+		return http.StatusInternalServerError, "Internal server error"
+	case errors.Is(result.Err, models.ErrNotFound):
+		return http.StatusNotFound, "The subscription is not found in the database"
+	case errors.Is(result.Err, models.ErrConflict):
+		return http.StatusConflict, "The subscription is already being stored in the database"
+	case errors.Is(result.Err, models.ErrPrecondition):
+		return http.StatusPreconditionFailed, "The subscription was changed by someone else"
+	case errors.Is(result.Err, models.ErrForbidden):
+		return http.StatusForbidden, "The action is forbidden for the credential"
+	case errors.Is(result.Err, models.ErrAborted):
+		return http.StatusFailedDependency, "The operation was aborted because another operation failed"
+	case result.Err != nil:
+		return http.StatusInternalServerError, "Unknown error"
+	case result.Op == models.BulkCreate:
+		return http.StatusCreated, "The subscription successfully created"
+	case result.Op == models.BulkUpdate:
+		return http.StatusOK, "The subscription was successfully updated"
+	default:
+		return http.StatusNoContent, "The subscription was successfully deleted"
+	}
 }
 
 // @Summary Get list of subscriptions
-// @Description The endpoint gets list of subscriptions. The list can be filtered by user uuid, service name, start date and end date
+// @Description The endpoint gets list of subscriptions. The list can be filtered by user uuids, service name matched exactly, by prefix or by substring and optionally ignoring case, price range, month the subscriptions are active on, presence of the end date, creation and update time, start date and end date. Deleted subscriptions are listed only if include_deleted is set. The list is sorted by the columns separated by commas, descending ones are prefixed by minus: id, service_name, price, currency, user_uuid, start_date, end_date, created_at and updated_at. The page starts next to the cursor if it is provided or at the offset otherwise, the cursors and the links to the next and previous pages are returned also as Link header. Total amount of the subscriptions is returned also as X-Total-Count header unless count is false
 // @Tags subscriptions
 // @Accept json
 // @Produce json
-// @Param user_uuid query string false "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+// @Security ApiKeyAuth
+// @Param user_uuid query []string false "60601fee-2bf1-4721-ae6f-7636e79a0cba" collectionFormat(csv)
 // @Param service_name query string false "Yandex Plus"
 // @Param start_date query string false "07-2025"
 // @Param end_date query string false "08-2025"
+// @Param category query string false "music"
+// @Param tag query []string false "streaming" collectionFormat(csv)
+// @Param service_name_match query string false "exact" Enums(exact, prefix, contains)
+// @Param ignore_case query bool false "false"
+// @Param price_min query int false "100"
+// @Param price_max query int false "1000"
+// @Param active_on query string false "07-2025"
+// @Param has_end_date query bool false "true"
+// @Param created_after query string false "2025-07-01T14:00:00Z"
+// @Param updated_after query string false "2025-07-01T14:00:00Z"
+// @Param include_deleted query bool false "false"
 // @Param limit query int false "10"
 // @Param offset query int false "0"
-// @Success 200 {array} models.Subscription
+// @Param cursor query string false "eyJpZCI6MTB9"
+// @Param count query bool false "true"
+// @Param sort query string false "price,-start_date,service_name"
+// @Success 200 {object} models.ListResponse
 // @Failure 400
+// @Failure 401
+// @Failure 403
 // @Failure 404
+// @Failure 429
 // @Failure 500
-// @Router /list [get]
+// @Router /api/v1/subscriptions [get]
+// @Router /subscriptions/list [get]
 func (h *Handler) List(c *gin.Context) {
 	// Getting query params
-	userUUID, err := uuid.Parse(c.DefaultQuery("user_uuid", "00000000-0000-0000-0000-000000000000"))
+	params, ok := queryPeriod(c)
+	if !ok {
+		return
+	}
+	var err error
+	params.IncludeDeleted, err = strconv.ParseBool(c.DefaultQuery("include_deleted", "false"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid user uuid", "error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid include deleted", "error": err.Error()})
 		return
 	}
-	serviceName := c.DefaultQuery("service_name", "")
-	// Getting start date
-	start := c.DefaultQuery("start_date", "")
-	var startDate models.CustomDate
-	if len(start) > 0 {
-		date, err := time.Parse("01-2006", start)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid time bounds", "error": err.Error()})
-			return
-		}
-		startDate = models.CustomDate{NullTime: sql.NullTime{Time: date, Valid: true}}
-	} else {
-		startDate.Valid = false
+
+	h.list(c, params)
+}
+
+// @Summary Get list of deleted subscriptions
+// @Description The endpoint gets list of subscriptions in the trash. The deleted subscriptions can be restored until they are purged after the retention period. The list can be filtered by user uuids, service name matched exactly, by prefix or by substring and optionally ignoring case, price range, month the subscriptions are active on, presence of the end date, creation and update time, start date and end date
+// @Tags subscriptions
+// @Produce json
+// @Security ApiKeyAuth
+// @Param user_uuid query []string false "60601fee-2bf1-4721-ae6f-7636e79a0cba" collectionFormat(csv)
+// @Param service_name query string false "Yandex Plus"
+// @Param start_date query string false "07-2025"
+// @Param end_date query string false "08-2025"
+// @Param category query string false "music"
+// @Param tag query []string false "streaming" collectionFormat(csv)
+// @Param service_name_match query string false "exact" Enums(exact, prefix, contains)
+// @Param ignore_case query bool false "false"
+// @Param price_min query int false "100"
+// @Param price_max query int false "1000"
+// @Param active_on query string false "07-2025"
+// @Param has_end_date query bool false "true"
+// @Param created_after query string false "2025-07-01T14:00:00Z"
+// @Param updated_after query string false "2025-07-01T14:00:00Z"
+// @Param limit query int false "10"
+// @Param offset query int false "0"
+// @Param cursor query string false "eyJpZCI6MTB9"
+// @Param count query bool false "true"
+// @Param sort query string false "price,-start_date,service_name"
+// @Success 200 {object} models.ListResponse
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 429
+// @Failure 500
+// @Router /api/v1/subscriptions/trash [get]
+func (h *Handler) Trash(c *gin.Context) {
+	// Getting query params
+	params, ok := queryPeriod(c)
+	if !ok {
+		return
 	}
-	// Getting end date
-	end := c.DefaultQuery("end_date", "")
-	var endDate models.CustomDate
-	if len(end) > 0 {
-		date, err := time.Parse("01-2006", end)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid time bounds", "error": err.Error()})
-			return
-		}
-		endDate = models.CustomDate{NullTime: sql.NullTime{Time: date, Valid: true}}
-	} else {
-		endDate.Valid = false
+	params.OnlyDeleted = true
+
+	h.list(c, params)
+}
+
+// @Summary Purge the trash
+// @Description The endpoint permanently deletes the tenant's subscriptions from the trash right away instead of waiting for the periodic purge. The whole trash is purged unless older than is provided, then only the subscriptions deleted at least that many days ago are purged. Amount of the purged subscriptions is returned
+// @Tags subscriptions
+// @Produce json
+// @Security ApiKeyAuth
+// @Param older_than query int false "Days since the deletion" example(7)
+// @Success 200 {object} models.PurgeResponse
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 429
+// @Failure 500
+// @Router /api/v1/subscriptions/trash [delete]
+func (h *Handler) Purge(c *gin.Context) {
+	// Getting the age of the subscriptions to purge
+	olderThan, err := strconv.Atoi(c.DefaultQuery("older_than", "0"))
+	if err != nil || olderThan < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid older than", "error": models.ErrBadRequest.Error()})
+		return
 	}
 
+	// Purging the trash
+	ctx := c.Request.Context()
+	purged, err := h.Service.Purge(ctx, time.Now().AddDate(0, 0, -olderThan))
+	switch {
+	case errors.Is(err, models.ErrInternalServer):
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "An error occured while purging the trash in the database", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"msg": "The action is forbidden for the credential", "error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "Unknown error", "error": err.Error()})
+		return
+	}
+
+	// Writing response
+	c.JSON(http.StatusOK, gin.H{"msg": "The trash was successfully purged", "body": models.PurgeResponse{Purged: purged}})
+}
+
+// Getting the page of subscriptions and writing it to the response
+func (h *Handler) list(c *gin.Context, params models.SubscriptionsWithinPeriod) {
+	if !queryFilters(c, &params) {
+		return
+	}
 	// Getting limit
-	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	var err error
+	params.Limit, err = strconv.Atoi(c.DefaultQuery("limit", "10"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid limit", "error": err.Error()})
 		return
 	}
 	// Getting offset
-	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	params.Offset, err = strconv.Atoi(c.DefaultQuery("offset", "0"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid offset", "error": err.Error()})
 		return
 	}
+	// Getting count, total amount of subscriptions can be skipped for speed
+	count, err := strconv.ParseBool(c.DefaultQuery("count", "true"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid count", "error": err.Error()})
+		return
+	}
+	params.SkipCount = !count
+	// Getting sort columns
+	if sort := c.Query("sort"); sort != "" {
+		params.Sort, err = models.ParseSort(sort)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid sort", "error": err.Error()})
+			return
+		}
+	}
+	// Getting cursor, the page starts next to it instead of the offset
+	if token := c.Query("cursor"); token != "" {
+		cursor, err := models.DecodeCursor(token)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid cursor", "error": err.Error()})
+			return
+		}
+		params.Cursor = &cursor
+	}
 
 	// Getting list of subscriptions from the database
 	ctx := c.Request.Context()
-	res, err := h.Service.List(ctx, models.SubscriptionsWithinPeriod{UserUUID: userUUID, ServiceName: serviceName, StartDate: startDate, EndDate: endDate, Limit: limit, Offset: offset})
+	res, err := h.Service.List(ctx, params)
 	switch {
 	case errors.Is(err, models.ErrBadRequest):
 		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid request", "error": err.Error()})
@@ -323,67 +1065,172 @@ func (h *Handler) List(c *gin.Context) {
 	case errors.Is(err, models.ErrInternalServer):
 		c.JSON(http.StatusInternalServerError, gin.H{"msg": "An error occured while getting subscriptions info from the database", "error": err.Error()})
 		return
+	case errors.Is(err, models.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"msg": "The action is forbidden for the credential", "error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "Unknown error", "error": err.Error()})
+		return
+	}
+
+	// Writing pagination headers
+	res.Links = pageLinks(c, params, res)
+	if res.Total != nil {
+		c.Header("X-Total-Count", strconv.Itoa(*res.Total))
+	}
+	if res.Links.Next != nil {
+		c.Writer.Header().Add("Link", "<"+*res.Links.Next+`>; rel="next"`)
+	}
+	if res.Links.Prev != nil {
+		c.Writer.Header().Add("Link", "<"+*res.Links.Prev+`>; rel="prev"`)
+	}
+
+	// Writing response
+	c.JSON(http.StatusOK, gin.H{"msg": "The subcriptions were successfully read", "body": res.Subscriptions, "total": res.Total, "limit": res.Limit,
+		"offset": res.Offset, "next_cursor": nullable(res.NextCursor), "prev_cursor": nullable(res.PrevCursor), "links": res.Links})
+}
+
+// Getting links to the next and previous pages keeping the other query params. The pages are being linked by the cursors if the cursor was provided
+// and by the offset otherwise
+func pageLinks(c *gin.Context, params models.SubscriptionsWithinPeriod, res models.ListResponse) models.ListLinks {
+	link := func(key, value string) *string {
+		query := c.Request.URL.Query()
+		query.Del("cursor")
+		query.Del("offset")
+		query.Set(key, value)
+		url := c.Request.URL.Path + "?" + query.Encode()
+		return &url
+	}
+
+	var links models.ListLinks
+	if params.Cursor != nil {
+		if res.NextCursor != "" {
+			links.Next = link("cursor", res.NextCursor)
+		}
+		if res.PrevCursor != "" {
+			links.Prev = link("cursor", res.PrevCursor)
+		}
+		return links
+	}
+	if res.NextCursor != "" {
+		links.Next = link("offset", strconv.Itoa(res.Offset+res.Limit))
+	}
+	// The previous page of the empty page past the end is the last page, it is omitted if the total count is skipped
+	switch {
+	case res.Offset == 0:
+	case len(res.Subscriptions) > 0:
+		links.Prev = link("offset", strconv.Itoa(max(res.Offset-res.Limit, 0)))
+	case res.Total != nil && *res.Total > 0 && res.Limit > 0:
+		links.Prev = link("offset", strconv.Itoa((*res.Total-1)/res.Limit*res.Limit))
+	}
+	return links
+}
+
+// Getting null instead of the empty string
+func nullable(value string) any {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+// @Summary Restore deleted subscription
+// @Description The endpoint moves the subscription back from the trash. The subscription is being specified by its id. If another subscription with the same user uuid and service name already exists in the database a conflict error will be thrown
+// @Tags subscriptions
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "1"
+// @Param If-Match header string false "\"2\""
+// @Success 200
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 404
+// @Failure 409
+// @Failure 412
+// @Failure 429
+// @Failure 500
+// @Router /api/v1/subscriptions/{id}/restore [post]
+func (h *Handler) Restore(c *gin.Context) {
+	// Getting path params
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	// Restoring the subscription
+	ctx := c.Request.Context()
+	err := h.Service.Restore(ctx, models.SubscriptionIdentifier{ID: id, Version: ifMatch(c)})
+	switch {
+	case errors.Is(err, models.ErrBadRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid request", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrInternalServer):
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "An error occured while restoring subscription in the database", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"msg": "The subscription is not found in the trash", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{"msg": "The subscription is already being stored in the database", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrPrecondition):
+		c.JSON(http.StatusPreconditionFailed, gin.H{"msg": "The subscription was changed by someone else", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"msg": "The action is forbidden for the credential", "error": err.Error()})
+		return
 	case err != nil:
 		c.JSON(http.StatusInternalServerError, gin.H{"msg": "Unknown error", "error": err.Error()})
 		return
 	}
 
 	// Writing response
-	c.JSON(http.StatusOK, gin.H{"msg": "The subcriptions were successfully read", "body": res})
+	c.JSON(http.StatusOK, gin.H{"msg": "The subscription was successfully restored"})
 }
 
 // @Summary Get total sum of subscriptions prices
-// @Description The endpoints returns total amount of unique subscriptions and calculates its total price within the provided period. It is implied that both of start date and end date is being paid. The subscriptions can be filtered by user id or service name
+// @Description The endpoints returns total amount of unique subscriptions and calculates its total price within the provided period. The total is being converted into the provided currency using the exchange rates, the used rates are returned. The summary can be grouped by service_name, user_uuid, month, category, tag and their combinations, the subscription is counted in the group of each of its tags, the totals of each group are returned alongside the grand total. The price effective at each billing date of the subscription (weekly, monthly, quarterly or yearly with the interval) that falls within the period is being charged, both of start date and end date months are included. The subscriptions can be filtered by user id, service name, category or tags
 // @Tags subscriptions
 // @Accept json
 // @Produce json
+// @Security ApiKeyAuth
 // @Param user_uuid query string false "60601fee-2bf1-4721-ae6f-7636e79a0cba"
 // @Param service_name query string false "Yandex Plus"
 // @Param start_date query string true "07-2025"
 // @Param end_date query string true "08-2025"
+// @Param category query string false "music"
+// @Param tag query []string false "streaming" collectionFormat(csv)
+// @Param currency query string false "RUB"
+// @Param group_by query []string false "service_name,month" collectionFormat(csv)
 // @Success 200 {object} models.SummaryResponse
 // @Failure 400
+// @Failure 401
+// @Failure 403
 // @Failure 404
+// @Failure 429
 // @Failure 500
-// @Router /summary [get]
+// @Router /api/v1/subscriptions/summary [get]
+// @Router /subscriptions/summary [get]
 func (h *Handler) Summary(c *gin.Context) {
 	// Getting query params
-	userUUID, err := uuid.Parse(c.DefaultQuery("user_uuid", "00000000-0000-0000-0000-000000000000"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid user uuid", "error": err.Error()})
+	params, ok := queryPeriod(c)
+	if !ok {
 		return
 	}
-	serviceName := c.DefaultQuery("service_name", "")
-	// Validating start date
-	start := c.DefaultQuery("start_date", "")
-	var startDate models.CustomDate
-	if len(start) > 0 {
-		date, err := time.Parse("01-2006", start)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid time bounds", "error": err.Error()})
-			return
+	params.Currency = c.DefaultQuery("currency", "")
+	// Getting grouping fields, they can be provided separated by comma or as repeated params
+	for _, fields := range c.QueryArray("group_by") {
+		for _, field := range strings.Split(fields, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				params.GroupBy = append(params.GroupBy, field)
+			}
 		}
-		startDate = models.CustomDate{NullTime: sql.NullTime{Time: date, Valid: true}}
-	} else {
-		startDate.Valid = false
-	}
-	// Validating end date
-	end := c.DefaultQuery("end_date", "")
-	var endDate models.CustomDate
-	if len(end) > 0 {
-		date, err := time.Parse("01-2006", end)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid time bounds", "error": err.Error()})
-			return
-		}
-		endDate = models.CustomDate{NullTime: sql.NullTime{Time: date, Valid: true}}
-	} else {
-		endDate.Valid = false
 	}
 
 	// Getting info from the database
 	ctx := c.Request.Context()
-	res, err := h.Service.Summary(ctx, models.SubscriptionsWithinPeriod{UserUUID: userUUID, ServiceName: serviceName, StartDate: startDate, EndDate: endDate})
+	res, err := h.Service.Summary(ctx, params)
 	switch {
 	case errors.Is(err, models.ErrBadRequest):
 		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid request", "error": err.Error()})
@@ -394,6 +1241,9 @@ func (h *Handler) Summary(c *gin.Context) {
 	case errors.Is(err, models.ErrNotFound):
 		c.JSON(http.StatusNotFound, gin.H{"msg": "The subscriptions are not found in the database", "error": err.Error()})
 		return
+	case errors.Is(err, models.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"msg": "The action is forbidden for the credential", "error": err.Error()})
+		return
 	case err != nil:
 		c.JSON(http.StatusInternalServerError, gin.H{"msg": "Unknown error", "error": err.Error()})
 		return
@@ -402,3 +1252,127 @@ func (h *Handler) Summary(c *gin.Context) {
 	// Writing response
 	c.JSON(http.StatusOK, gin.H{"msg": "The total sum was successfully calculated", "body": res})
 }
+
+// @Summary Get time series of subscriptions
+// @Description The endpoint returns amount of active, new and cancelled subscriptions and their spend within each bucket (week, month, quarter or year) of the provided period. The buckets are bounded by the period, the spend is being converted into the provided currency or the base currency. The subscriptions can be filtered by user id, service name, category or tags
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param user_uuid query string false "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+// @Param service_name query string false "Yandex Plus"
+// @Param start_date query string true "07-2025"
+// @Param end_date query string true "08-2025"
+// @Param category query string false "music"
+// @Param tag query []string false "streaming" collectionFormat(csv)
+// @Param bucket query string false "month" Enums(week, month, quarter, year)
+// @Param currency query string false "RUB"
+// @Success 200 {object} models.TimeseriesResponse
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 429
+// @Failure 500
+// @Router /api/v1/subscriptions/timeseries [get]
+// @Router /subscriptions/timeseries [get]
+func (h *Handler) Timeseries(c *gin.Context) {
+	// Getting query params
+	params, ok := queryPeriod(c)
+	if !ok {
+		return
+	}
+	params.Bucket = c.DefaultQuery("bucket", "")
+	params.Currency = c.DefaultQuery("currency", "")
+
+	// Getting time series from the database
+	ctx := c.Request.Context()
+	res, err := h.Service.Timeseries(ctx, params)
+	switch {
+	case errors.Is(err, models.ErrBadRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid request", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrInternalServer):
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "An error occured while getting subscriptions info from the database", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"msg": "The action is forbidden for the credential", "error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "Unknown error", "error": err.Error()})
+		return
+	}
+
+	// Writing response
+	c.JSON(http.StatusOK, gin.H{"msg": "The time series was successfully calculated", "body": res})
+}
+
+// @Summary Get exchange rates
+// @Description The endpoint returns exchange rates used to convert the totals between currencies. Each rate is the price of one unit of the currency in the base currency. The tenant gets the loaded rates until it replaces them
+// @Tags rates
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} models.ExchangeRates
+// @Failure 401
+// @Failure 403
+// @Failure 429
+// @Failure 500
+// @Router /api/v1/rates [get]
+// @Router /subscriptions/rates [get]
+func (h *Handler) Rates(c *gin.Context) {
+	// Getting exchange rates
+	ctx := c.Request.Context()
+	res, err := h.Service.Rates(ctx)
+	switch {
+	case errors.Is(err, models.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"msg": "The action is forbidden for the credential", "error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "Unknown error", "error": err.Error()})
+		return
+	}
+
+	// Writing response
+	c.JSON(http.StatusOK, gin.H{"msg": "The exchange rates were successfully read", "body": res})
+}
+
+// @Summary Replace exchange rates
+// @Description The endpoint replaces exchange rates used to convert the totals between currencies. Each rate is the price of one unit of the currency in the base currency, the base currency should match the configured one. The rates are replaced only for the tenant of the client, the other tenants keep theirs
+// @Tags rates
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param rates body models.ExchangeRates true "Exchange rates"
+// @Success 200
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 429
+// @Failure 500
+// @Router /api/v1/rates [put]
+// @Router /subscriptions/rates [put]
+func (h *Handler) SetRates(c *gin.Context) {
+	// Reading request's body
+	var rates models.ExchangeRates
+	if err := c.ShouldBindJSON(&rates); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Error while reading request's body", "error": err.Error()})
+		return
+	}
+
+	// Replacing exchange rates
+	ctx := c.Request.Context()
+	err := h.Service.SetRates(ctx, rates)
+	switch {
+	case errors.Is(err, models.ErrBadRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid request", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"msg": "The action is forbidden for the credential", "error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "Unknown error", "error": err.Error()})
+		return
+	}
+
+	// Writing response
+	c.JSON(http.StatusOK, gin.H{"msg": "The exchange rates were successfully updated"})
+}
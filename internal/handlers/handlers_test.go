@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/middelmatigheid/subscriptions-api/internal/auth"
+	"github.com/middelmatigheid/subscriptions-api/internal/config"
+	"github.com/middelmatigheid/subscriptions-api/internal/memory"
+	"github.com/middelmatigheid/subscriptions-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Users of the stored subscriptions, the member acts on behalf of alice
+var (
+	alice = uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba")
+	bob   = uuid.MustParse("1b1f7f5e-6f4c-4b8e-9c1a-2d3e4f5a6b7c")
+)
+
+// Secret the members' tokens are signed with
+const secret = "secret"
+
+// Getting the token of the member acting on behalf of the user
+func memberToken(t *testing.T, user uuid.UUID) string {
+	encode := func(part map[string]any) string {
+		b, err := json.Marshal(part)
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	signed := encode(map[string]any{"alg": "HS256"}) + "." + encode(map[string]any{"sub": user.String(), "exp": time.Now().Add(time.Hour).Unix()})
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Getting the router serving the api to the clients of the api keys named by their scope and to the members' tokens. Alice has Yandex Plus
+// subscription with id 1, bob has Kinopoisk subscription with id 2 and Okko subscription with id 3 in the trash. The catalog has Netflix service
+// with id 1
+func newRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	m, ctx := memory.NewMemory(), context.Background()
+	handler, err := NewHandler(&config.Config{RatesBase: "RUB", Cache: "none", ImportMaxSize: 1}, m, nil)
+	if err != nil {
+		t.Fatalf("NewHandler() error = %v", err)
+	}
+	tokens, err := auth.NewTokens(&config.Config{JWTSecret: secret})
+	if err != nil {
+		t.Fatalf("NewTokens() error = %v", err)
+	}
+	for _, scope := range []string{models.ScopeRead, models.ScopeWrite, models.ScopeAdmin} {
+		key := models.APIKey{Name: scope, Scopes: []string{scope}, TenantID: models.DefaultTenant, Hash: auth.HashKey(scope + "_key")}
+		if _, err = m.CreateAPIKey(ctx, key); err != nil {
+			t.Fatalf("CreateAPIKey() error = %v", err)
+		}
+	}
+	var start models.CustomDate
+	start.Time, start.Valid = time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC), true
+	for _, subscription := range []models.Subscription{
+		{ServiceName: "Yandex Plus", Price: 400, UserUUID: alice},
+		{ServiceName: "Kinopoisk", Price: 300, UserUUID: bob},
+		{ServiceName: "Okko", Price: 200, UserUUID: bob},
+	} {
+		subscription.Currency, subscription.BillingUnit, subscription.BillingInterval, subscription.StartDate = "RUB", models.BillingMonth, 1, start
+		if _, err = m.Create(ctx, subscription); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+	if err = m.Delete(ctx, models.SubscriptionIdentifier{ID: 3}); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, _, err = m.CreateService(ctx, models.CatalogService{Name: "Netflix", Currency: "RUB"}); err != nil {
+		t.Fatalf("CreateService() error = %v", err)
+	}
+
+	server := gin.New()
+	read, write, admin := auth.Require(models.ScopeRead), auth.Require(models.ScopeWrite), auth.Require(models.ScopeAdmin)
+	api := server.Group("/api/v1", auth.Middleware(m, "", tokens))
+	resources := api.Group("/subscriptions")
+	resources.POST("", write, handler.Create)
+	resources.POST("/bulk", write, handler.Bulk)
+	resources.POST("/import", write, handler.Import)
+	resources.GET("/export", read, handler.Export)
+	resources.GET("", read, handler.List)
+	resources.GET("/summary", read, handler.Summary)
+	resources.GET("/timeseries", read, handler.Timeseries)
+	resources.GET("/trash", read, handler.Trash)
+	resources.DELETE("/trash", admin, handler.Purge)
+	resources.GET("/:id", read, handler.ReadByID)
+	resources.PUT("/:id", write, handler.UpdateByID)
+	resources.PATCH("/:id", write, handler.PatchByID)
+	resources.DELETE("/:id", write, handler.DeleteByID)
+	resources.POST("/:id/restore", write, handler.Restore)
+	resources.GET("/:id/history", read, handler.History)
+	resources.GET("/:id/prices", read, handler.Prices)
+	resources.POST("/:id/prices", write, handler.SchedulePrice)
+	services := api.Group("/services")
+	services.POST("", admin, handler.CreateService)
+	services.GET("", read, handler.ListServices)
+	services.GET("/:id", read, handler.ReadService)
+	services.PUT("/:id", admin, handler.UpdateService)
+	services.DELETE("/:id", admin, handler.DeleteService)
+	keys := api.Group("/keys", admin)
+	keys.POST("", handler.IssueAPIKey)
+	keys.GET("", handler.ListAPIKeys)
+	keys.DELETE("/:id", handler.RevokeAPIKey)
+	api.GET("/rates", read, handler.Rates)
+	api.PUT("/rates", admin, handler.SetRates)
+	return server
+}
+
+// Making the request as the client of the role
+func serve(t *testing.T, server *gin.Engine, role string, method string, path string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	switch role {
+	case models.RoleAdmin:
+		req.Header.Set("X-API-Key", "admin_key")
+	case models.RoleEditor:
+		req.Header.Set("X-API-Key", "write_key")
+	case models.RoleAuditor:
+		req.Header.Set("X-API-Key", "read_key")
+	case models.RoleMember:
+		req.Header.Set("Authorization", "Bearer "+memberToken(t, alice))
+	}
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	return w
+}
+
+func TestRoles(t *testing.T) {
+	subscription := func(user uuid.UUID, service string) string {
+		return `{"service_name": "` + service + `", "price": 500, "user_uuid": "` + user.String() + `", "start_date": "07-2025"}`
+	}
+	bulk := `{"mode": "all_or_nothing", "operations": [{"op": "create", "subscription": ` + subscription(bob, "Ivi") + `}]}`
+	csv := "service_name,price,user_uuid,start_date\nIvi,400," + bob.String() + ",07-2025\n"
+	period := "?start_date=07-2025&end_date=08-2025"
+
+	// Statuses are listed for admin, editor, auditor and member. Each request is made to the router of its own, so the requests don't affect each other
+	roles := []string{models.RoleAdmin, models.RoleEditor, models.RoleAuditor, models.RoleMember}
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   [4]int
+	}{
+		{"create for other user", http.MethodPost, "/api/v1/subscriptions", subscription(bob, "Ivi"), [4]int{201, 201, 403, 403}},
+		{"create for own user", http.MethodPost, "/api/v1/subscriptions", subscription(alice, "Ivi"), [4]int{201, 201, 403, 201}},
+		{"read own", http.MethodGet, "/api/v1/subscriptions/1", "", [4]int{200, 200, 200, 200}},
+		{"read other's", http.MethodGet, "/api/v1/subscriptions/2", "", [4]int{200, 200, 200, 404}},
+		{"update own", http.MethodPut, "/api/v1/subscriptions/1", subscription(alice, "Yandex Plus"), [4]int{200, 200, 403, 200}},
+		{"update other's", http.MethodPut, "/api/v1/subscriptions/2", subscription(bob, "Kinopoisk"), [4]int{200, 200, 403, 404}},
+		{"take over other's", http.MethodPut, "/api/v1/subscriptions/2", subscription(alice, "Kinopoisk"), [4]int{200, 200, 403, 404}},
+		{"patch own", http.MethodPatch, "/api/v1/subscriptions/1", `{"price": 500}`, [4]int{200, 200, 403, 200}},
+		{"patch other's", http.MethodPatch, "/api/v1/subscriptions/2", `{"price": 500}`, [4]int{200, 200, 403, 404}},
+		{"delete own", http.MethodDelete, "/api/v1/subscriptions/1", "", [4]int{204, 204, 403, 204}},
+		{"delete other's", http.MethodDelete, "/api/v1/subscriptions/2", "", [4]int{204, 204, 403, 404}},
+		{"restore other's", http.MethodPost, "/api/v1/subscriptions/3/restore", "", [4]int{200, 200, 403, 404}},
+		{"history of own", http.MethodGet, "/api/v1/subscriptions/1/history", "", [4]int{200, 200, 200, 200}},
+		{"history of other's", http.MethodGet, "/api/v1/subscriptions/2/history", "", [4]int{200, 200, 200, 404}},
+		{"prices of other's", http.MethodGet, "/api/v1/subscriptions/2/prices", "", [4]int{200, 200, 200, 404}},
+		{"schedule price of own", http.MethodPost, "/api/v1/subscriptions/1/prices", `{"price": 500, "effective_from": "09-2025"}`, [4]int{201, 201, 403, 201}},
+		{"schedule price of other's", http.MethodPost, "/api/v1/subscriptions/2/prices", `{"price": 500, "effective_from": "09-2025"}`, [4]int{201, 201, 403, 404}},
+		{"list", http.MethodGet, "/api/v1/subscriptions", "", [4]int{200, 200, 200, 200}},
+		{"list of other user", http.MethodGet, "/api/v1/subscriptions?user_uuid=" + bob.String(), "", [4]int{200, 200, 200, 403}},
+		{"summary", http.MethodGet, "/api/v1/subscriptions/summary" + period, "", [4]int{200, 200, 200, 200}},
+		{"timeseries", http.MethodGet, "/api/v1/subscriptions/timeseries" + period, "", [4]int{200, 200, 200, 200}},
+		{"trash", http.MethodGet, "/api/v1/subscriptions/trash", "", [4]int{200, 200, 200, 200}},
+		{"purge", http.MethodDelete, "/api/v1/subscriptions/trash", "", [4]int{200, 403, 403, 403}},
+		{"bulk", http.MethodPost, "/api/v1/subscriptions/bulk", bulk, [4]int{200, 403, 403, 403}},
+		{"import", http.MethodPost, "/api/v1/subscriptions/import", csv, [4]int{201, 403, 403, 403}},
+		{"export", http.MethodGet, "/api/v1/subscriptions/export", "", [4]int{200, 200, 200, 200}},
+		{"create service", http.MethodPost, "/api/v1/services", `{"name": "Ivi"}`, [4]int{201, 403, 403, 403}},
+		{"list services", http.MethodGet, "/api/v1/services", "", [4]int{200, 200, 200, 200}},
+		{"read service", http.MethodGet, "/api/v1/services/1", "", [4]int{200, 200, 200, 200}},
+		{"update service", http.MethodPut, "/api/v1/services/1", `{"name": "Netflix", "category": "video"}`, [4]int{200, 403, 403, 403}},
+		{"delete service", http.MethodDelete, "/api/v1/services/1", "", [4]int{204, 403, 403, 403}},
+		{"issue api key", http.MethodPost, "/api/v1/keys", `{"name": "billing export", "scopes": ["read"]}`, [4]int{201, 403, 403, 403}},
+		{"list api keys", http.MethodGet, "/api/v1/keys", "", [4]int{200, 403, 403, 403}},
+		{"revoke api key", http.MethodDelete, "/api/v1/keys/1", "", [4]int{204, 403, 403, 403}},
+		{"rates", http.MethodGet, "/api/v1/rates", "", [4]int{200, 200, 200, 200}},
+		{"set rates", http.MethodPut, "/api/v1/rates", `{"base": "RUB", "rates": {"USD": 90}}`, [4]int{200, 403, 403, 403}},
+	}
+	for _, tt := range tests {
+		for i, role := range roles {
+			t.Run(tt.name+" as "+role, func(t *testing.T) {
+				w := serve(t, newRouter(t), role, tt.method, tt.path, tt.body)
+				if w.Code != tt.want[i] {
+					t.Fatalf("%s %s = %d %s, want %d", tt.method, tt.path, w.Code, w.Body.String(), tt.want[i])
+				}
+				if w.Code != http.StatusForbidden {
+					return
+				}
+				var body map[string]any
+				if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+					t.Fatalf("Unmarshal() error = %v", err)
+				}
+				if body["msg"] == nil || body["error"] != models.ErrForbidden.Error() {
+					t.Errorf("%s %s body = %v, want msg and forbidden error", tt.method, tt.path, body)
+				}
+			})
+		}
+	}
+}
+
+func TestMemberList(t *testing.T) {
+	server := newRouter(t)
+
+	// The member reaches only the subscriptions of the user, the trash of other users is hidden as well
+	for _, path := range []string{"/api/v1/subscriptions", "/api/v1/subscriptions/trash", "/api/v1/subscriptions/1/history"} {
+		w := serve(t, server, models.RoleMember, http.MethodGet, path, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s = %d %s, want %d", path, w.Code, w.Body.String(), http.StatusOK)
+		}
+		if strings.Contains(w.Body.String(), bob.String()) {
+			t.Errorf("GET %s = %s, want no subscriptions of other users", path, w.Body.String())
+		}
+	}
+	w := serve(t, server, models.RoleMember, http.MethodGet, "/api/v1/subscriptions", "")
+	var body struct {
+		Body []models.Subscription `json:"body"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if len(body.Body) != 1 || body.Body[0].UserUUID != alice {
+		t.Errorf("GET /api/v1/subscriptions = %+v, want the subscription of alice", body.Body)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	server := newRouter(t)
+
+	// The trash is purged right away unless the age is provided
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantPurged int
+	}{
+		{"negative age", "/api/v1/subscriptions/trash?older_than=-1", http.StatusBadRequest, 0},
+		{"older than the trash", "/api/v1/subscriptions/trash?older_than=7", http.StatusOK, 0},
+		{"whole trash", "/api/v1/subscriptions/trash", http.StatusOK, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(t, server, models.RoleAdmin, http.MethodDelete, tt.path, "")
+			if w.Code != tt.wantStatus {
+				t.Fatalf("DELETE %s = %d %s, want %d", tt.path, w.Code, w.Body.String(), tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var body struct {
+				Body models.PurgeResponse `json:"body"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if body.Body.Purged != tt.wantPurged {
+				t.Errorf("DELETE %s purged = %d, want %d", tt.path, body.Body.Purged, tt.wantPurged)
+			}
+		})
+	}
+}
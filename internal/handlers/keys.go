@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/middelmatigheid/subscriptions-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Issue a new api key
+// @Description The endpoint issues a new api key with the scopes: read allows reading the subscriptions, write allows changing them one by one as well, admin allows everything including the bulk operations, the import, purging the trash and changing the catalog, the rates and the api keys. The key is returned only once, only its hash is stored. The key doesn't expire if the expiration time is not provided
+// @Tags keys
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param key body models.APIKey true "Api key data"
+// @Success 201 {object} models.APIKey
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 429
+// @Failure 500
+// @Router /api/v1/keys [post]
+func (h *Handler) IssueAPIKey(c *gin.Context) {
+	// Reading request's body
+	var key models.APIKey
+	if err := c.ShouldBindJSON(&key); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Error while reading request's body", "error": err.Error()})
+		return
+	}
+
+	// Issuing the key
+	ctx := c.Request.Context()
+	res, err := h.Service.IssueAPIKey(ctx, key)
+	switch {
+	case errors.Is(err, models.ErrBadRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid request", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrInternalServer):
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "An error occured while inserting api key into the database", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"msg": "The action is forbidden for the credential", "error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "Unknown error", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"msg": "The api key was successfully issued", "body": res})
+}
+
+// @Summary Get list of api keys
+// @Description The endpoint gets the issued api keys ordered by id including expired and revoked ones. The keys themselves are not returned
+// @Tags keys
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} models.APIKey
+// @Failure 401
+// @Failure 403
+// @Failure 429
+// @Failure 500
+// @Router /api/v1/keys [get]
+func (h *Handler) ListAPIKeys(c *gin.Context) {
+	ctx := c.Request.Context()
+	res, err := h.Service.ListAPIKeys(ctx)
+	switch {
+	case errors.Is(err, models.ErrInternalServer):
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "An error occured while getting api keys from the database", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"msg": "The action is forbidden for the credential", "error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "Unknown error", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"msg": "The api keys were successfully read", "body": res})
+}
+
+// @Summary Revoke api key
+// @Description The endpoint revokes the api key specified by its id. The revoked key is rejected since then, the key is kept in the list
+// @Tags keys
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "1"
+// @Success 204
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 404
+// @Failure 429
+// @Failure 500
+// @Router /api/v1/keys/{id} [delete]
+func (h *Handler) RevokeAPIKey(c *gin.Context) {
+	// Getting path params
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	// Revoking the key
+	ctx := c.Request.Context()
+	err := h.Service.RevokeAPIKey(ctx, id)
+	switch {
+	case errors.Is(err, models.ErrBadRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid request", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrInternalServer):
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "An error occured while revoking api key in the database", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"msg": "The api key is not found", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"msg": "The action is forbidden for the credential", "error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "Unknown error", "error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
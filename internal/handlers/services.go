@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/middelmatigheid/subscriptions-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Create a new service
+// @Description The endpoint inserts a new service into the catalog. Service names of the subscriptions matching the name or any of the aliases ignoring case and spaces are replaced by the canonical name, the default price in the service's currency is used if the subscription doesn't provide the price. The stored subscriptions matching the names are linked to the service and renamed unless the user already has the subscription of the canonical name. If any of the names is already used by another service a conflict error will be thrown
+// @Tags services
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param service body models.CatalogService true "Service data"
+// @Success 201 {object} models.IDResponse
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 409 {object} models.IDResponse
+// @Failure 429
+// @Failure 500
+// @Router /api/v1/services [post]
+func (h *Handler) CreateService(c *gin.Context) {
+	// Reading request's body
+	var service models.CatalogService
+	if err := c.ShouldBindJSON(&service); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Error while reading request's body", "error": err.Error()})
+		return
+	}
+
+	// Inserting the service into the catalog
+	ctx := c.Request.Context()
+	res, err := h.Service.CreateService(ctx, service)
+	switch {
+	case errors.Is(err, models.ErrBadRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid request", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrInternalServer):
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "Internal server error", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{"msg": "The service's name is already used in the catalog", "error": err.Error(), "body": res})
+		return
+	case errors.Is(err, models.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"msg": "The action is forbidden for the credential", "error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "Unknown error", "error": err.Error()})
+		return
+	}
+
+	// Writing response
+	c.Header("Location", "/api/v1/services/"+strconv.Itoa(res.ID))
+	c.JSON(http.StatusCreated, gin.H{"msg": "The service was successfully created", "body": res})
+}
+
+// @Summary Get list of services
+// @Description The endpoint gets services of the catalog ordered by name. The services can be filtered by category
+// @Tags services
+// @Produce json
+// @Security ApiKeyAuth
+// @Param category query string false "music"
+// @Success 200 {array} models.CatalogService
+// @Failure 401
+// @Failure 403
+// @Failure 429
+// @Failure 500
+// @Router /api/v1/services [get]
+func (h *Handler) ListServices(c *gin.Context) {
+	ctx := c.Request.Context()
+	res, err := h.Service.ListServices(ctx, c.Query("category"))
+	switch {
+	case errors.Is(err, models.ErrInternalServer):
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "An error occured while getting services from the database", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"msg": "The action is forbidden for the credential", "error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "Unknown error", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"msg": "The services were successfully read", "body": res})
+}
+
+// @Summary Get service information
+// @Description The endpoint returns info of the service of the catalog. The service is being specified by its id
+// @Tags services
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "1"
+// @Success 200 {object} models.CatalogService
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 404
+// @Failure 429
+// @Failure 500
+// @Router /api/v1/services/{id} [get]
+func (h *Handler) ReadService(c *gin.Context) {
+	// Getting path params
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	// Getting service's info from the database
+	ctx := c.Request.Context()
+	res, err := h.Service.ReadService(ctx, id)
+	switch {
+	case errors.Is(err, models.ErrBadRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid request", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrInternalServer):
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "An error occured while getting service info from the database", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"msg": "The service is not found in the catalog", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"msg": "The action is forbidden for the credential", "error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "Unknown error", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"msg": "The service was successfully read", "body": res})
+}
+
+// @Summary Update service
+// @Description The endpoint updates service of the catalog. The service is being specified by its id. All fields should be provided. The subscriptions referencing the service are renamed and the ones matching the new names are linked to it, the previous name is kept as an alias so it is still resolved. If any of the names is already used by another service a conflict error will be thrown
+// @Tags services
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "1"
+// @Param service body models.CatalogService true "Service data"
+// @Success 200
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 404
+// @Failure 409
+// @Failure 429
+// @Failure 500
+// @Router /api/v1/services/{id} [put]
+func (h *Handler) UpdateService(c *gin.Context) {
+	// Getting path params
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	// Reading request's body
+	var service models.CatalogService
+	if err := c.ShouldBindJSON(&service); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Error while reading request's body", "error": err.Error()})
+		return
+	}
+	if service.ID != 0 && service.ID != id {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid request", "error": "Id in the path and in the body differ"})
+		return
+	}
+	service.ID = id
+
+	// Updating the service's info
+	ctx := c.Request.Context()
+	err := h.Service.UpdateService(ctx, service)
+	switch {
+	case errors.Is(err, models.ErrBadRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid request", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrInternalServer):
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "An error occured while updating service info in the database", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"msg": "The service is not found in the catalog", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{"msg": "The service's name is already used in the catalog", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"msg": "The action is forbidden for the credential", "error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "Unknown error", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"msg": "The service was successfully updated"})
+}
+
+// @Summary Delete service
+// @Description The endpoint deletes service from the catalog. The service is being specified by its id. The service referenced by any subscription, including the ones in the trash, can't be deleted
+// @Tags services
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "1"
+// @Success 204
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 404
+// @Failure 409
+// @Failure 429
+// @Failure 500
+// @Router /api/v1/services/{id} [delete]
+func (h *Handler) DeleteService(c *gin.Context) {
+	// Getting path params
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	// Deleting the service from the catalog
+	ctx := c.Request.Context()
+	err := h.Service.DeleteService(ctx, id)
+	switch {
+	case errors.Is(err, models.ErrBadRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid request", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrInternalServer):
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "An error occured while deleting service from the database", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"msg": "The service is not found in the catalog", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{"msg": "The service is referenced by subscriptions", "error": err.Error()})
+		return
+	case errors.Is(err, models.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"msg": "The action is forbidden for the credential", "error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "Unknown error", "error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,119 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/middelmatigheid/subscriptions-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Headers of the response being replayed alongside its status and body. The rate limit headers are set by the limiter for the retried request
+// itself, so they aren't replayed
+var replayedHeaders = []string{"Content-Type", "Location", "ETag"}
+
+// Checking if the request may succeed when it is retried later, the responses with such statuses are not being kept
+func transient(status int) bool {
+	switch status {
+	case http.StatusRequestTimeout, http.StatusTooEarly, http.StatusTooManyRequests:
+		return true
+	}
+	return status >= http.StatusInternalServerError
+}
+
+// Custom response writer for catching the response
+type writer struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *writer) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *writer) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Middleware replays the responses to the mutating requests retried with the same Idempotency-Key header. The key reused for another request is
+// rejected with 422 and the key of the request being processed is rejected with 409. Responses with server errors and the other transient statuses
+// are not being kept so the request can be retried. The keys expire after ttl. The body being hashed is read up to maxSize bytes, the larger body is
+// rejected with 413
+func Middleware(storage models.IdempotencyStorage, ttl time.Duration, maxSize int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" || c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead || c.Request.Method == http.MethodOptions {
+			c.Next()
+			return
+		}
+
+		// Hashing the request, so the key can't be reused for another one
+		var tooLarge *http.MaxBytesError
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxSize))
+		if errors.As(err, &tooLarge) {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"msg": "The request's body is too large", "error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"msg": "Error while reading request's body", "error": err.Error()})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.Method + " " + c.Request.URL.RequestURI() + "\n"))
+		hash.Write(body)
+		record := models.IdempotencyRecord{Key: key, RequestHash: hex.EncodeToString(hash.Sum(nil))}
+
+		// Reserving the key, the response is being replayed if the key was already used for the same request
+		ctx := context.WithoutCancel(c.Request.Context())
+		existing, err := storage.ReserveIdempotencyKey(ctx, record, time.Now().Add(-ttl))
+		switch {
+		case errors.Is(err, models.ErrConflict) && existing.RequestHash != record.RequestHash:
+			c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"msg": "The idempotency key was already used for another request",
+				"error": http.StatusText(http.StatusUnprocessableEntity)})
+			return
+		case errors.Is(err, models.ErrConflict) && existing.Status == 0:
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"msg": "The request with the idempotency key is being processed", "error": err.Error()})
+			return
+		case errors.Is(err, models.ErrConflict):
+			for name, value := range existing.Headers {
+				c.Header(name, value)
+			}
+			c.Header("Idempotent-Replayed", "true")
+			c.Writer.WriteHeader(existing.Status)
+			c.Writer.Write(existing.Body)
+			c.Abort()
+			return
+		case err != nil:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"msg": "An error occured while reserving idempotency key", "error": err.Error()})
+			return
+		}
+
+		// Processing the request and keeping its response
+		w := &writer{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		if transient(w.Status()) {
+			storage.ReleaseIdempotencyKey(ctx, key)
+			return
+		}
+		record.Status, record.Body, record.Headers = w.Status(), w.body.Bytes(), make(map[string]string)
+		for _, name := range replayedHeaders {
+			if value := w.Header().Get(name); value != "" {
+				record.Headers[name] = value
+			}
+		}
+		if err = storage.SaveIdempotencyResponse(ctx, record); err != nil {
+			storage.ReleaseIdempotencyKey(ctx, key)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package idempotency
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/middelmatigheid/subscriptions-api/internal/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Getting the router keeping the responses of the handler, the handler responds with the status of X-Status header and the amount of its calls
+func newRouter(middleware gin.HandlerFunc, calls *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	server := gin.New()
+	server.POST("/", middleware, func(c *gin.Context) {
+		*calls++
+		status, err := strconv.Atoi(c.GetHeader("X-Status"))
+		if err != nil {
+			status = http.StatusCreated
+		}
+		c.Header("Location", "/"+strconv.Itoa(*calls))
+		c.JSON(status, gin.H{"calls": *calls})
+	})
+	return server
+}
+
+// Making the request with the idempotency key
+func post(server *gin.Engine, key string, body string, status int) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	if status != 0 {
+		req.Header.Set("X-Status", strconv.Itoa(status))
+	}
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddleware(t *testing.T) {
+	calls := 0
+	server := newRouter(Middleware(memory.NewMemory(), time.Hour, 16), &calls)
+
+	// The cases are run in order, each of them sees the keys reserved by the previous ones
+	tests := []struct {
+		name         string
+		key          string
+		body         string
+		status       int
+		wantStatus   int
+		wantCalls    int
+		wantReplayed bool
+	}{
+		{"first request", "create", `{"price": 400}`, 0, http.StatusCreated, 1, false},
+		{"retried request", "create", `{"price": 400}`, 0, http.StatusCreated, 1, true},
+		{"other body", "create", `{"price": 500}`, 0, http.StatusUnprocessableEntity, 1, false},
+		{"client error", "invalid", `{}`, http.StatusBadRequest, http.StatusBadRequest, 2, false},
+		{"retried client error", "invalid", `{}`, 0, http.StatusBadRequest, 2, true},
+		{"server error", "failing", `{}`, http.StatusInternalServerError, http.StatusInternalServerError, 3, false},
+		{"retried after server error", "failing", `{}`, 0, http.StatusCreated, 4, false},
+		{"rate limited", "limited", `{}`, http.StatusTooManyRequests, http.StatusTooManyRequests, 5, false},
+		{"retried after rate limit", "limited", `{}`, 0, http.StatusCreated, 6, false},
+		{"too large body", "large", strings.Repeat("a", 17), 0, http.StatusRequestEntityTooLarge, 6, false},
+		{"without key", "", `{}`, 0, http.StatusCreated, 7, false},
+		{"retried without key", "", `{}`, 0, http.StatusCreated, 8, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := post(server, tt.key, tt.body, tt.status)
+			if w.Code != tt.wantStatus || calls != tt.wantCalls {
+				t.Fatalf("POST / = %d %s after %d calls, want %d after %d calls", w.Code, w.Body.String(), calls, tt.wantStatus, tt.wantCalls)
+			}
+			if replayed := w.Header().Get("Idempotent-Replayed") == "true"; replayed != tt.wantReplayed {
+				t.Errorf("POST / replayed = %t, want %t", replayed, tt.wantReplayed)
+			}
+		})
+	}
+
+	// The replayed response keeps the headers of the original one
+	if w := post(server, "create", `{"price": 400}`, 0); w.Header().Get("Location") != "/1" || w.Body.String() != `{"calls":1}` {
+		t.Errorf("POST / replayed = %v %s, want the first response", w.Header(), w.Body.String())
+	}
+}
+
+func TestMiddlewareInFlight(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	started, release := make(chan struct{}), make(chan struct{})
+	server := gin.New()
+	server.POST("/", Middleware(memory.NewMemory(), time.Hour, 16), func(c *gin.Context) {
+		close(started)
+		<-release
+		c.Status(http.StatusCreated)
+	})
+
+	done := make(chan int)
+	go func() {
+		done <- post(server, "create", `{}`, 0).Code
+	}()
+	<-started
+
+	// The key is reserved until the first request is processed
+	if w := post(server, "create", `{}`, 0); w.Code != http.StatusConflict {
+		t.Errorf("POST / in flight = %d, want %d", w.Code, http.StatusConflict)
+	}
+	close(release)
+	if status := <-done; status != http.StatusCreated {
+		t.Errorf("POST / = %d, want %d", status, http.StatusCreated)
+	}
+}
@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"maps"
+
+	"github.com/middelmatigheid/subscriptions-api/internal/models"
+)
+
+// Bulk executes the operations either all or nothing or each independently. If an operation fails in all-or-nothing mode, the stored subscriptions
+// are restored and the other operations are aborted
+func (m *Memory) Bulk(ctx context.Context, operations []models.BulkOperation, allOrNothing bool) ([]models.BulkResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Keeping the state to restore it on failure
+	subscriptions, lastID, events := maps.Clone(m.subscriptions), m.lastID, len(m.events)
+
+	results := make([]models.BulkResult, len(operations))
+	failed := -1
+	for i, operation := range operations {
+		results[i] = models.BulkResult{Index: i, Op: operation.Op}
+		if failed >= 0 {
+			results[i].Err = models.NewErrAborted()
+			continue
+		}
+		results[i].ID, results[i].Err = m.apply(ctx, operation)
+		if results[i].Err != nil && allOrNothing {
+			failed = i
+		}
+	}
+	if failed >= 0 {
+		for i := range failed {
+			results[i].Err = models.NewErrAborted()
+			if results[i].Op == models.BulkCreate {
+				results[i].ID = 0
+			}
+		}
+		m.subscriptions, m.lastID, m.events = subscriptions, lastID, m.events[:events]
+	}
+	return results, nil
+}
+
+// Applying the operation and returning id of the subscription. The lock should be held by the caller
+func (m *Memory) apply(ctx context.Context, operation models.BulkOperation) (int, error) {
+	switch operation.Op {
+	case models.BulkCreate:
+		res, err := m.create(ctx, operation.Subscription)
+		return res.ID, err
+	case models.BulkUpdate:
+		return operation.Subscription.ID, m.update(ctx, operation.Subscription)
+	case models.BulkDelete:
+		return operation.ID, m.remove(ctx, models.SubscriptionIdentifier{ID: operation.ID, Version: operation.Version})
+	}
+	return 0, models.NewErrBadRequest(errors.New("Unknown operation " + operation.Op))
+}
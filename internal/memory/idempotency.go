@@ -0,0 +1,64 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/middelmatigheid/subscriptions-api/internal/models"
+)
+
+// ReserveIdempotencyKey stores the key of the request being processed, the keys of different tenants and of different clients of the tenant don't
+// collide. The key created before the expiration time is being replaced as if it was never used. If the key is already reserved its record is
+// returned with the conflict error
+func (m *Memory) ReserveIdempotencyKey(ctx context.Context, record models.IdempotencyRecord, expired time.Time) (models.IdempotencyRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Reserving the key
+	key := idempotencyKey{models.TenantFromContext(ctx), models.SubjectFromContext(ctx), record.Key}
+	if existing, ok := m.idempotency[key]; ok && !existing.CreatedAt.Before(expired) {
+		return existing, models.NewErrConflict()
+	}
+	record.CreatedAt = time.Now()
+	m.idempotency[key] = record
+	return record, nil
+}
+
+// SaveIdempotencyResponse stores the response to the request made with the reserved key
+func (m *Memory) SaveIdempotencyResponse(ctx context.Context, record models.IdempotencyRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := idempotencyKey{models.TenantFromContext(ctx), models.SubjectFromContext(ctx), record.Key}
+	existing, ok := m.idempotency[key]
+	if !ok {
+		return models.NewErrInternalServer(errors.New("Idempotency key is not reserved"))
+	}
+	existing.Status, existing.Headers, existing.Body = record.Status, record.Headers, record.Body
+	m.idempotency[key] = existing
+	return nil
+}
+
+// ReleaseIdempotencyKey drops the key so the request can be made with it again
+func (m *Memory) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.idempotency, idempotencyKey{models.TenantFromContext(ctx), models.SubjectFromContext(ctx), key})
+	return nil
+}
+
+// PurgeIdempotencyKeys drops the keys of all the tenants and clients created before the expiration time and returns their amount
+func (m *Memory) PurgeIdempotencyKeys(ctx context.Context, expired time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	purged := 0
+	for key, existing := range m.idempotency {
+		if existing.CreatedAt.Before(expired) {
+			delete(m.idempotency, key)
+			purged++
+		}
+	}
+	return purged, nil
+}
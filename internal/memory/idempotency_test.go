@@ -0,0 +1,42 @@
+package memory
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/middelmatigheid/subscriptions-api/internal/models"
+)
+
+func TestIdempotencyKeys(t *testing.T) {
+	m, ctx := NewMemory(), tenantContext(models.DefaultTenant)
+	record := models.IdempotencyRecord{Key: "retry", RequestHash: "first"}
+	if _, err := m.ReserveIdempotencyKey(ctx, record, time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("ReserveIdempotencyKey() error = %v", err)
+	}
+
+	// The key is reserved until it expires
+	record.RequestHash = "second"
+	existing, err := m.ReserveIdempotencyKey(ctx, record, time.Now().Add(-time.Hour))
+	if !errors.Is(err, models.ErrConflict) || existing.RequestHash != "first" {
+		t.Errorf("ReserveIdempotencyKey() reserved = %+v, %v, want conflict with the first request", existing, err)
+	}
+	if _, err = m.ReserveIdempotencyKey(tenantContext("sales"), record, time.Now().Add(-time.Hour)); err != nil {
+		t.Errorf("ReserveIdempotencyKey() in other tenant error = %v", err)
+	}
+	other := models.WithCredential(ctx, models.Credential{Subject: "key 2", TenantID: models.DefaultTenant})
+	if _, err = m.ReserveIdempotencyKey(other, record, time.Now().Add(-time.Hour)); err != nil {
+		t.Errorf("ReserveIdempotencyKey() by other client error = %v", err)
+	}
+
+	// The expired key is replaced even if it wasn't purged yet
+	reserved, err := m.ReserveIdempotencyKey(ctx, record, time.Now().Add(time.Minute))
+	if err != nil || reserved.RequestHash != "second" {
+		t.Errorf("ReserveIdempotencyKey() expired = %+v, %v, want the second request reserved", reserved, err)
+	}
+
+	purged, err := m.PurgeIdempotencyKeys(ctx, time.Now().Add(time.Minute))
+	if err != nil || purged != 3 {
+		t.Errorf("PurgeIdempotencyKeys() = %d, %v, want 3 purged", purged, err)
+	}
+}
@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/middelmatigheid/subscriptions-api/internal/models"
+)
+
+// CreateAPIKey stores hash of the new api key of the tenant and returns its id
+func (m *Memory) CreateAPIKey(ctx context.Context, key models.APIKey) (models.IDResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, stored := range m.apiKeys {
+		if stored.Hash == key.Hash {
+			return models.IDResponse{}, models.NewErrConflict()
+		}
+	}
+	key.ID = len(m.apiKeys) + 1
+	key.Key = ""
+	key.Scopes = slices.Clone(key.Scopes)
+	key.CreatedAt = models.CustomTime{}
+	key.CreatedAt.Time, key.CreatedAt.Valid = time.Now(), true
+	m.apiKeys = append(m.apiKeys, key)
+	return models.IDResponse{ID: key.ID}, nil
+}
+
+// ReadAPIKey returns the api key specified by the hash of the key, expired and revoked keys are returned as well. The key is looked for among all
+// the tenants since the tenant is resolved from it
+func (m *Memory) ReadAPIKey(ctx context.Context, hash string) (models.APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, key := range m.apiKeys {
+		if key.Hash == hash {
+			key.Scopes = slices.Clone(key.Scopes)
+			return key, nil
+		}
+	}
+	return models.APIKey{}, models.NewErrNotFound()
+}
+
+// ListAPIKeys returns the api keys of the tenant ordered by id, the hashes are not being returned
+func (m *Memory) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys, tenant := []models.APIKey{}, models.TenantFromContext(ctx)
+	for _, key := range m.apiKeys {
+		if key.TenantID != tenant {
+			continue
+		}
+		key.Hash = ""
+		key.Scopes = slices.Clone(key.Scopes)
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey revokes the api key of the tenant specified by its id, the time of the first revocation is kept
+func (m *Memory) RevokeAPIKey(ctx context.Context, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if id <= 0 || id > len(m.apiKeys) || m.apiKeys[id-1].TenantID != models.TenantFromContext(ctx) {
+		return models.NewErrNotFound()
+	}
+	if key := &m.apiKeys[id-1]; !key.RevokedAt.Valid {
+		key.RevokedAt.Time, key.RevokedAt.Valid = time.Now(), true
+	}
+	return nil
+}
+
+// TouchAPIKey stores the time the api key was last used at
+func (m *Memory) TouchAPIKey(ctx context.Context, id int, usedAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if id > 0 && id <= len(m.apiKeys) {
+		m.apiKeys[id-1].LastUsedAt.Time, m.apiKeys[id-1].LastUsedAt.Valid = usedAt, true
+	}
+	return nil
+}
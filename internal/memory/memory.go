@@ -0,0 +1,701 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/middelmatigheid/subscriptions-api/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Memory is an in-memory storage of subscriptions. It follows the same rules as the PostgreSQL database and is used to run the api without external services
+type Memory struct {
+	mu            sync.RWMutex
+	subscriptions map[int]models.Subscription
+	lastID        int
+	events        []models.SubscriptionEvent
+	prices        map[int][]models.PriceChange
+	idempotency   map[idempotencyKey]models.IdempotencyRecord
+	services      map[int]models.CatalogService
+	serviceNames  map[tenantKey]int
+	lastServiceID int
+	apiKeys       []models.APIKey
+}
+
+// Key of the value unique within the tenant
+type tenantKey struct {
+	tenant, key string
+}
+
+// Idempotency key unique within the client of the tenant
+type idempotencyKey struct {
+	tenant, subject, key string
+}
+
+// NewMemory creates an empty in-memory storage
+func NewMemory() *Memory {
+	return &Memory{subscriptions: make(map[int]models.Subscription), prices: make(map[int][]models.PriceChange),
+		idempotency: make(map[idempotencyKey]models.IdempotencyRecord), services: make(map[int]models.CatalogService), serviceNames: make(map[tenantKey]int)}
+}
+
+// Close drops all the stored subscriptions
+func (m *Memory) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subscriptions = make(map[int]models.Subscription)
+	m.events = nil
+	m.prices = make(map[int][]models.PriceChange)
+	m.idempotency = make(map[idempotencyKey]models.IdempotencyRecord)
+	m.services = make(map[int]models.CatalogService)
+	m.serviceNames = make(map[tenantKey]int)
+	m.apiKeys = nil
+	return nil
+}
+
+// Create stores new subscription and returns its id, if the insertion was successful, or returs id of conflicting subscription
+func (m *Memory) Create(ctx context.Context, subscription models.Subscription) (models.IDResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.create(ctx, subscription)
+}
+
+// Storing new subscription and recording its creation. The lock should be held by the caller
+func (m *Memory) create(ctx context.Context, subscription models.Subscription) (models.IDResponse, error) {
+	// Checking if the subscription is being already stored
+	sub, err := m.read(ctx, models.SubscriptionIdentifier{UserUUID: subscription.UserUUID, ServiceName: subscription.ServiceName})
+	if err == nil {
+		return models.IDResponse{ID: sub.ID}, models.NewErrConflict()
+	}
+
+	// Storing the subscription
+	m.lastID++
+	subscription.ID = m.lastID
+	subscription.TenantID = models.TenantFromContext(ctx)
+	subscription.Version = 1
+	subscription.Tags = sortedTags(subscription.Tags)
+	subscription.CreatedAt = models.CustomTime{}
+	subscription.CreatedAt.Time, subscription.CreatedAt.Valid = time.Now(), true
+	subscription.UpdatedAt = subscription.CreatedAt
+	m.subscriptions[subscription.ID] = subscription
+	m.recordEvent(ctx, models.EventCreate, nil, &subscription)
+	return models.IDResponse{ID: subscription.ID}, nil
+}
+
+// Read returns the stored subscription. The subscription is being specified by its id or combination of user uuid and service name,
+// deleted subscriptions are not being read
+func (m *Memory) Read(ctx context.Context, identifier models.SubscriptionIdentifier) (models.Subscription, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.read(ctx, identifier)
+}
+
+// Update updates the stored subscription. The subscription is being specified by its id, the subscription is updated only if its version matches
+func (m *Memory) Update(ctx context.Context, subscription models.Subscription) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.update(ctx, subscription)
+}
+
+// Updating the subscription and recording its states before and after the update. The lock should be held by the caller
+func (m *Memory) update(ctx context.Context, subscription models.Subscription) error {
+	// Checking if the same subscription is being stored
+	exists, err := m.read(ctx, models.SubscriptionIdentifier{UserUUID: subscription.UserUUID, ServiceName: subscription.ServiceName})
+	if err == nil && subscription.ID != exists.ID {
+		return models.NewErrConflict()
+	}
+
+	// Updating the subscription
+	stored, ok := m.subscriptions[subscription.ID]
+	if !ok || stored.DeletedAt.Valid || stored.TenantID != models.TenantFromContext(ctx) {
+		return models.NewErrNotFound()
+	}
+	subscription.TenantID = stored.TenantID
+	if subscription.Version != 0 && subscription.Version != stored.Version {
+		return models.NewErrPrecondition()
+	}
+	subscription.Version = stored.Version + 1
+	subscription.Tags = sortedTags(subscription.Tags)
+	subscription.CreatedAt = stored.CreatedAt
+	subscription.UpdatedAt = models.CustomTime{}
+	subscription.UpdatedAt.Time, subscription.UpdatedAt.Valid = time.Now(), true
+	m.subscriptions[subscription.ID] = subscription
+	m.recordEvent(ctx, models.EventUpdate, &stored, &subscription)
+	return nil
+}
+
+// Delete moves the stored subscription to the trash. The subscriptions can be specified by its id or combination of user uuid and service name,
+// the subscription is deleted only if its version matches
+func (m *Memory) Delete(ctx context.Context, identifier models.SubscriptionIdentifier) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.remove(ctx, identifier)
+}
+
+// Moving the subscription to the trash and recording its states before and after the deletion. The lock should be held by the caller
+func (m *Memory) remove(ctx context.Context, identifier models.SubscriptionIdentifier) error {
+	subscription, err := m.read(ctx, identifier)
+	if err != nil {
+		return err
+	}
+	if identifier.Version != 0 && identifier.Version != subscription.Version {
+		return models.NewErrPrecondition()
+	}
+	before := subscription
+	subscription.Version++
+	subscription.DeletedAt = models.CustomTime{}
+	subscription.DeletedAt.Time, subscription.DeletedAt.Valid = time.Now(), true
+	subscription.UpdatedAt = subscription.DeletedAt
+	m.subscriptions[subscription.ID] = subscription
+	m.recordEvent(ctx, models.EventDelete, &before, &subscription)
+	return nil
+}
+
+// Restore moves the subscription specified by its id back from the trash, if there is no other subscription of the same user and service.
+// The subscription is restored only if its version matches and it belongs to the user if the user uuid is provided
+func (m *Memory) Restore(ctx context.Context, identifier models.SubscriptionIdentifier) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	subscription, ok := m.subscriptions[identifier.ID]
+	if !ok || !subscription.DeletedAt.Valid || subscription.TenantID != models.TenantFromContext(ctx) ||
+		(identifier.UserUUID != uuid.Nil && subscription.UserUUID != identifier.UserUUID) {
+		return models.NewErrNotFound()
+	}
+	if _, err := m.read(ctx, models.SubscriptionIdentifier{UserUUID: subscription.UserUUID, ServiceName: subscription.ServiceName}); err == nil {
+		return models.NewErrConflict()
+	}
+	if identifier.Version != 0 && identifier.Version != subscription.Version {
+		return models.NewErrPrecondition()
+	}
+	before := subscription
+	subscription.Version++
+	subscription.DeletedAt = models.CustomTime{}
+	subscription.UpdatedAt.Time, subscription.UpdatedAt.Valid = time.Now(), true
+	m.subscriptions[subscription.ID] = subscription
+	m.recordEvent(ctx, models.EventRestore, &before, &subscription)
+	return nil
+}
+
+// Purge permanently deletes the tenant's subscriptions which were moved to the trash before the time and returns their amount
+func (m *Memory) Purge(ctx context.Context, before time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	purged, tenant := 0, models.TenantFromContext(ctx)
+	for _, subscription := range m.sorted() {
+		if subscription.TenantID == tenant && subscription.DeletedAt.Valid && subscription.DeletedAt.Time.Before(before) {
+			delete(m.subscriptions, subscription.ID)
+			delete(m.prices, subscription.ID)
+			m.recordEvent(ctx, models.EventPurge, &subscription, nil)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// TrashTenants returns the tenants which have subscriptions moved to the trash before the time, so the trash of each tenant can be purged
+func (m *Memory) TrashTenants(ctx context.Context, before time.Time) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tenants := []string{}
+	for _, subscription := range m.sorted() {
+		if subscription.DeletedAt.Valid && subscription.DeletedAt.Time.Before(before) && !slices.Contains(tenants, subscription.TenantID) {
+			tenants = append(tenants, subscription.TenantID)
+		}
+	}
+	slices.Sort(tenants)
+	return tenants, nil
+}
+
+// SchedulePrice sets the price of the tenant's subscription effective from the month, the price already scheduled for the month is being replaced.
+// The scheduled price is recorded in the subscription's history
+func (m *Memory) SchedulePrice(ctx context.Context, id int, change models.PriceChange) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	subscription, ok := m.subscriptions[id]
+	if !ok || subscription.DeletedAt.Valid || subscription.TenantID != models.TenantFromContext(ctx) {
+		return models.NewErrNotFound()
+	}
+
+	change.CreatedAt = models.CustomTime{}
+	change.CreatedAt.Time, change.CreatedAt.Valid = time.Now(), true
+	changes := slices.DeleteFunc(m.prices[id], func(scheduled models.PriceChange) bool {
+		return scheduled.EffectiveFrom.Time.Equal(change.EffectiveFrom.Time)
+	})
+	changes = append(changes, change)
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].EffectiveFrom.Time.Before(changes[j].EffectiveFrom.Time)
+	})
+	m.prices[id] = changes
+	m.recordEvent(ctx, models.EventSchedulePrice, &subscription, &subscription)
+	m.events[len(m.events)-1].Price = &change
+	return nil
+}
+
+// Prices returns the price changes of the tenant's subscription ordered by the month they are effective from
+func (m *Memory) Prices(ctx context.Context, id int) ([]models.PriceChange, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if subscription, ok := m.subscriptions[id]; !ok || subscription.TenantID != models.TenantFromContext(ctx) {
+		return []models.PriceChange{}, nil
+	}
+	return append([]models.PriceChange{}, m.prices[id]...), nil
+}
+
+// History returns the changes of the tenant's subscription in order they were made
+func (m *Memory) History(ctx context.Context, id int) ([]models.SubscriptionEvent, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	events, tenant := []models.SubscriptionEvent{}, models.TenantFromContext(ctx)
+	for _, event := range m.events {
+		if event.SubscriptionID == id && event.TenantID == tenant {
+			events = append(events, event)
+		}
+	}
+	if len(events) == 0 {
+		return nil, models.NewErrNotFound()
+	}
+	return events, nil
+}
+
+// Recording the change of the subscription made by the actor within the request of the context. The event belongs to the subscription's tenant.
+// The lock should be held by the caller
+func (m *Memory) recordEvent(ctx context.Context, action string, before, after *models.Subscription) {
+	event := models.SubscriptionEvent{ID: len(m.events) + 1, Action: action, Actor: models.ActorFromContext(ctx), ActorLabel: models.ActorLabelFromContext(ctx),
+		RequestID: models.RequestIDFromContext(ctx)}
+	if before != nil {
+		snapshot := *before
+		event.SubscriptionID, event.TenantID, event.Before = snapshot.ID, snapshot.TenantID, &snapshot
+	}
+	if after != nil {
+		snapshot := *after
+		event.SubscriptionID, event.TenantID, event.After = snapshot.ID, snapshot.TenantID, &snapshot
+	}
+	event.CreatedAt.Time, event.CreatedAt.Valid = time.Now(), true
+	m.events = append(m.events, event)
+}
+
+// List returns an array of subscriptions sorted by the columns and then by id. The list of subscriptions can be filtered by the period, user uuid and service name. The page
+// starts next to the cursor if it is provided
+func (m *Memory) List(ctx context.Context, params models.SubscriptionsWithinPeriod) ([]models.Subscription, error) {
+	if params.Limit < 0 || params.Offset < 0 {
+		return []models.Subscription{}, models.NewErrInternalServer(errors.New("Negative limit or offset"))
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// The page is being read backwards from the cursor to get the previous one
+	order, backward := params.Order(), params.Cursor != nil && params.Cursor.Backward
+	var cursor models.Subscription
+	if params.Cursor != nil {
+		var err error
+		if cursor, err = params.Cursor.Subscription(order); err != nil {
+			return []models.Subscription{}, models.NewErrInternalServer(err)
+		}
+	}
+	sorted := m.sorted()
+	slices.SortStableFunc(sorted, func(a, b models.Subscription) int {
+		return compareSubscriptions(a, b, order)
+	})
+	if backward {
+		slices.Reverse(sorted)
+	}
+
+	var subscriptions []models.Subscription
+	for _, subscription := range sorted {
+		if !matchesPeriod(ctx, subscription, params) {
+			continue
+		}
+		if params.Cursor != nil && (backward && compareSubscriptions(subscription, cursor, order) >= 0 ||
+			!backward && compareSubscriptions(subscription, cursor, order) <= 0) {
+			continue
+		}
+		if params.Offset > 0 {
+			params.Offset--
+			continue
+		}
+		if len(subscriptions) == params.Limit {
+			break
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+	if backward {
+		slices.Reverse(subscriptions)
+	}
+	return subscriptions, nil
+}
+
+// Count returns amount of subscriptions filtered the same way as the list
+func (m *Memory) Count(ctx context.Context, params models.SubscriptionsWithinPeriod) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	count := 0
+	for _, subscription := range m.subscriptions {
+		if matchesPeriod(ctx, subscription, params) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Export passes each subscription filtered the same way as the list to the function in order of id. The export stops on the function's error
+func (m *Memory) Export(ctx context.Context, params models.SubscriptionsWithinPeriod, fn func(models.Subscription) error) error {
+	// Copying the subscriptions so the lock isn't held while they are being passed
+	m.mu.RLock()
+	var subscriptions []models.Subscription
+	for _, subscription := range m.sorted() {
+		if matchesPeriod(ctx, subscription, params) {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	m.mu.RUnlock()
+
+	for _, subscription := range subscriptions {
+		if err := fn(subscription); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Summary return amount of subscriptions within the provided period and total amount that was payed in each currency. The price effective at each
+// billing date of the subscription within the period is being charged. The subscriptions can be filtered by the period, user uuid and service name
+// and grouped by service name, user uuid, month, category and tag
+func (m *Memory) Summary(ctx context.Context, params models.SubscriptionsWithinPeriod) (models.SummaryResponse, error) {
+	if !params.StartDate.Valid || !params.EndDate.Valid {
+		return models.SummaryResponse{}, models.NewErrInternalServer(errors.New("Period is not provided"))
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	summary := models.SummaryResponse{Months: models.Months(params.StartDate.Time, params.EndDate.Time), Totals: []models.CurrencyTotal{}}
+
+	// Getting grand total
+	if groups := m.summaryGroups(ctx, params, nil); len(groups) > 0 {
+		summary.Amount, summary.Totals = groups[0].Amount, groups[0].Totals
+	}
+
+	// Getting totals of each group
+	if len(params.GroupBy) > 0 {
+		summary.Groups = m.summaryGroups(ctx, params, params.GroupBy)
+	}
+	return summary, nil
+}
+
+// Getting totals in each currency of the subscriptions grouped by the fields. The lock should be held by the caller
+func (m *Memory) summaryGroups(ctx context.Context, params models.SubscriptionsWithinPeriod, groupBy []string) []models.SummaryGroup {
+	type group struct {
+		summary models.SummaryGroup
+		order   []string
+		totals  map[string]*models.CurrencyTotal
+	}
+	groups := make(map[string]*group)
+
+	for _, subscription := range m.subscriptions {
+		if !matchesPeriod(ctx, subscription, params) {
+			continue
+		}
+
+		// Charges are being counted within the period or within each month of the period if the summary is grouped by month
+		periods := [][2]time.Time{{params.StartDate.Time, params.EndDate.Time}}
+		if slices.Contains(groupBy, models.GroupByMonth) {
+			periods = nil
+			month := params.StartDate.Time
+			if subscription.StartDate.Time.After(month) {
+				month = subscription.StartDate.Time
+			}
+			for ; !month.After(params.EndDate.Time) && !(subscription.EndDate.Valid && month.After(subscription.EndDate.Time)); month = month.AddDate(0, 1, 0) {
+				periods = append(periods, [2]time.Time{month, month})
+			}
+		}
+
+		// The subscription is counted in the group of each of its tags
+		tags := []string{""}
+		if len(subscription.Tags) > 0 && slices.Contains(groupBy, models.GroupByTag) {
+			tags = subscription.Tags
+		}
+
+		for _, period := range periods {
+			for _, tag := range tags {
+				// Getting the group of the subscription
+				values, order := make(map[string]string, len(groupBy)), make([]string, len(groupBy))
+				for i, field := range groupBy {
+					switch field {
+					case models.GroupByServiceName:
+						values[field], order[i] = subscription.ServiceName, subscription.ServiceName
+					case models.GroupByUserUUID:
+						values[field], order[i] = subscription.UserUUID.String(), subscription.UserUUID.String()
+					case models.GroupByMonth:
+						values[field], order[i] = period[0].Format("01-2006"), period[0].Format("2006-01")
+					case models.GroupByCategory:
+						values[field], order[i] = subscription.Category, subscription.Category
+					case models.GroupByTag:
+						values[field], order[i] = tag, tag
+					}
+				}
+				key := strings.Join(order, "\x00")
+				g, ok := groups[key]
+				if !ok {
+					g = &group{summary: models.SummaryGroup{Group: values}, order: order, totals: make(map[string]*models.CurrencyTotal)}
+					groups[key] = g
+				}
+
+				// Counting the charges in the subscription's currency
+				total, ok := g.totals[subscription.Currency]
+				if !ok {
+					total = &models.CurrencyTotal{Currency: subscription.Currency}
+					g.totals[subscription.Currency] = total
+				}
+				total.Amount++
+				for _, date := range subscription.BillingDates(period[0], period[1]) {
+					total.Total += subscription.PriceAt(m.prices[subscription.ID], date)
+				}
+			}
+		}
+	}
+
+	// Ordering the groups by the fields and their totals by currency
+	sorted := make([]*group, 0, len(groups))
+	for _, g := range groups {
+		g.summary.Totals = []models.CurrencyTotal{}
+		for _, total := range g.totals {
+			g.summary.Amount += total.Amount
+			g.summary.Totals = append(g.summary.Totals, *total)
+		}
+		sort.Slice(g.summary.Totals, func(i, j int) bool {
+			return g.summary.Totals[i].Currency < g.summary.Totals[j].Currency
+		})
+		sorted = append(sorted, g)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return slices.Compare(sorted[i].order, sorted[j].order) < 0
+	})
+
+	result := make([]models.SummaryGroup, 0, len(sorted))
+	for _, g := range sorted {
+		result = append(result, g.summary)
+	}
+	return result
+}
+
+// Timeseries returns amount of active, new and cancelled subscriptions and total amount that was payed in each currency within each bucket of the period.
+// The subscriptions can be filtered by the period, user uuid and service name
+func (m *Memory) Timeseries(ctx context.Context, params models.SubscriptionsWithinPeriod) ([]models.TimeseriesBucket, error) {
+	if !params.StartDate.Valid || !params.EndDate.Valid {
+		return nil, models.NewErrInternalServer(errors.New("Period is not provided"))
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// The buckets are bounded by the period
+	last := params.EndDate.Time.AddDate(0, 1, -1)
+	buckets := []models.TimeseriesBucket{}
+	for start := models.BucketStart(params.Bucket, params.StartDate.Time); !start.After(last); start = models.BucketNext(params.Bucket, start) {
+		bucketStart, bucketEnd := start, models.BucketNext(params.Bucket, start).AddDate(0, 0, -1)
+		if bucketStart.Before(params.StartDate.Time) {
+			bucketStart = params.StartDate.Time
+		}
+		if bucketEnd.After(last) {
+			bucketEnd = last
+		}
+
+		bucket := models.TimeseriesBucket{Start: bucketStart.Format("02-01-2006"), End: bucketEnd.Format("02-01-2006"), Totals: []models.CurrencyTotal{}}
+		totals := make(map[string]*models.CurrencyTotal)
+		for _, subscription := range m.subscriptions {
+			// The subscription is being paid until the last day of its end month
+			endsAt := subscription.EndDate.Time.AddDate(0, 1, -1)
+			if !matchesPeriod(ctx, subscription, params) || subscription.StartDate.Time.After(bucketEnd) || (subscription.EndDate.Valid && endsAt.Before(bucketStart)) {
+				continue
+			}
+
+			bucket.Active++
+			if !subscription.StartDate.Time.Before(bucketStart) {
+				bucket.New++
+			}
+			if subscription.EndDate.Valid && !endsAt.After(bucketEnd) {
+				bucket.Cancelled++
+			}
+
+			// Counting the charges in the subscription's currency
+			total, ok := totals[subscription.Currency]
+			if !ok {
+				total = &models.CurrencyTotal{Currency: subscription.Currency}
+				totals[subscription.Currency] = total
+			}
+			total.Amount++
+			for _, date := range subscription.BillingDates(bucketStart, params.EndDate.Time) {
+				if !date.After(bucketEnd) {
+					total.Total += subscription.PriceAt(m.prices[subscription.ID], date)
+				}
+			}
+		}
+
+		for _, total := range totals {
+			bucket.Totals = append(bucket.Totals, *total)
+		}
+		sort.Slice(bucket.Totals, func(i, j int) bool {
+			return bucket.Totals[i].Currency < bucket.Totals[j].Currency
+		})
+		buckets = append(buckets, bucket)
+	}
+	return buckets, nil
+}
+
+// Looking for the first not deleted subscription of the tenant matching the identifier. The lock should be held by the caller
+func (m *Memory) read(ctx context.Context, identifier models.SubscriptionIdentifier) (models.Subscription, error) {
+	tenant := models.TenantFromContext(ctx)
+	for _, subscription := range m.sorted() {
+		if subscription.DeletedAt.Valid || subscription.TenantID != tenant {
+			continue
+		}
+		if identifier.ID > 0 && subscription.ID != identifier.ID {
+			continue
+		}
+		if identifier.UserUUID != uuid.Nil && subscription.UserUUID != identifier.UserUUID {
+			continue
+		}
+		if identifier.ServiceName != "" && subscription.ServiceName != identifier.ServiceName {
+			continue
+		}
+		return subscription, nil
+	}
+	return models.Subscription{}, models.NewErrNotFound()
+}
+
+// Getting stored subscriptions ordered by id. The lock should be held by the caller
+func (m *Memory) sorted() []models.Subscription {
+	subscriptions := make([]models.Subscription, 0, len(m.subscriptions))
+	for _, subscription := range m.subscriptions {
+		subscriptions = append(subscriptions, subscription)
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].ID < subscriptions[j].ID
+	})
+	return subscriptions
+}
+
+// Getting copy of the tags ordered by name
+func sortedTags(tags []string) []string {
+	tags = append([]string{}, tags...)
+	slices.Sort(tags)
+	return tags
+}
+
+// Comparing the subscriptions by the columns in order. Subscriptions without the end date are the last ones in ascending order as in the database
+func compareSubscriptions(a, b models.Subscription, order []models.SortKey) int {
+	for _, key := range order {
+		var c int
+		switch key.Column {
+		case "service_name":
+			c = strings.Compare(a.ServiceName, b.ServiceName)
+		case "price":
+			c = cmp.Compare(a.Price, b.Price)
+		case "currency":
+			c = strings.Compare(a.Currency, b.Currency)
+		case "user_uuid":
+			c = strings.Compare(a.UserUUID.String(), b.UserUUID.String())
+		case "start_date":
+			c = a.StartDate.Time.Compare(b.StartDate.Time)
+		case "end_date":
+			switch {
+			case a.EndDate.Valid && b.EndDate.Valid:
+				c = a.EndDate.Time.Compare(b.EndDate.Time)
+			case a.EndDate.Valid:
+				c = -1
+			case b.EndDate.Valid:
+				c = 1
+			}
+		case "created_at":
+			c = a.CreatedAt.Time.Compare(b.CreatedAt.Time)
+		case "updated_at":
+			c = a.UpdatedAt.Time.Compare(b.UpdatedAt.Time)
+		case "id":
+			c = cmp.Compare(a.ID, b.ID)
+		}
+		if key.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// Checking if the subscription belongs to the tenant and matches user uuids, service name, price, dates, category, tags and intersects the period. Deleted subscriptions are matched only if
+// they are requested
+func matchesPeriod(ctx context.Context, subscription models.Subscription, params models.SubscriptionsWithinPeriod) bool {
+	if subscription.TenantID != models.TenantFromContext(ctx) {
+		return false
+	}
+	if params.OnlyDeleted && !subscription.DeletedAt.Valid || !params.OnlyDeleted && !params.IncludeDeleted && subscription.DeletedAt.Valid {
+		return false
+	}
+	if len(params.UserUUIDs) > 0 && !slices.Contains(params.UserUUIDs, subscription.UserUUID) {
+		return false
+	}
+	if params.ServiceName != "" && !matchesServiceName(subscription.ServiceName, params) {
+		return false
+	}
+	if params.PriceMin > 0 && subscription.Price < params.PriceMin || params.PriceMax > 0 && subscription.Price > params.PriceMax {
+		return false
+	}
+	if params.EndDate.Valid && subscription.StartDate.Time.After(params.EndDate.Time) {
+		return false
+	}
+	if params.StartDate.Valid && subscription.EndDate.Valid && subscription.EndDate.Time.Before(params.StartDate.Time) {
+		return false
+	}
+	if params.ActiveOn.Valid && (subscription.StartDate.Time.After(params.ActiveOn.Time) ||
+		subscription.EndDate.Valid && subscription.EndDate.Time.Before(params.ActiveOn.Time)) {
+		return false
+	}
+	if params.HasEndDate != nil && *params.HasEndDate != subscription.EndDate.Valid {
+		return false
+	}
+	if params.CreatedAfter.Valid && !subscription.CreatedAt.Time.After(params.CreatedAfter.Time) {
+		return false
+	}
+	if params.UpdatedAfter.Valid && !subscription.UpdatedAt.Time.After(params.UpdatedAfter.Time) {
+		return false
+	}
+	if params.Category != "" && subscription.Category != params.Category {
+		return false
+	}
+	for _, tag := range params.Tags {
+		if !slices.Contains(subscription.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// Checking if the service name matches exactly, by the prefix or by the substring
+func matchesServiceName(serviceName string, params models.SubscriptionsWithinPeriod) bool {
+	pattern := params.ServiceName
+	if params.IgnoreCase {
+		serviceName, pattern = strings.ToLower(serviceName), strings.ToLower(pattern)
+	}
+	switch params.ServiceNameMatch {
+	case models.MatchPrefix:
+		return strings.HasPrefix(serviceName, pattern)
+	case models.MatchContains:
+		return strings.Contains(serviceName, pattern)
+	}
+	return serviceName == pattern
+}
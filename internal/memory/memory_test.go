@@ -0,0 +1,322 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/middelmatigheid/subscriptions-api/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Getting the context of the client acting within the tenant
+func tenantContext(tenant string) context.Context {
+	return models.WithCredential(context.Background(), models.Credential{Name: "test", Subject: "key 1", TenantID: tenant, Role: models.RoleAdmin})
+}
+
+// Getting the date of the first day of the month
+func date(year int, month time.Month) models.CustomDate {
+	var cd models.CustomDate
+	cd.Time, cd.Valid = time.Date(year, month, 1, 0, 0, 0, 0, time.UTC), true
+	return cd
+}
+
+// Getting the monthly subscription of the user
+func subscription(user uuid.UUID, service string, price int) models.Subscription {
+	return models.Subscription{ServiceName: service, Price: price, Currency: "RUB", BillingUnit: models.BillingMonth, BillingInterval: 1, UserUUID: user,
+		StartDate: date(2025, time.July)}
+}
+
+func TestCreateRead(t *testing.T) {
+	m, ctx, user := NewMemory(), tenantContext(models.DefaultTenant), uuid.New()
+
+	created, err := m.Create(ctx, subscription(user, "Yandex Plus", 400))
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if created.ID != 1 {
+		t.Errorf("Create() id = %d, want 1", created.ID)
+	}
+
+	for _, identifier := range []models.SubscriptionIdentifier{{ID: created.ID}, {UserUUID: user, ServiceName: "Yandex Plus"}} {
+		got, err := m.Read(ctx, identifier)
+		if err != nil {
+			t.Fatalf("Read(%+v) error = %v", identifier, err)
+		}
+		if got.ID != created.ID || got.Price != 400 || got.Version != 1 || !got.CreatedAt.Valid {
+			t.Errorf("Read(%+v) = %+v", identifier, got)
+		}
+	}
+
+	// The same user can't have two subscriptions of the service, id of the stored one is returned
+	conflict, err := m.Create(ctx, subscription(user, "Yandex Plus", 500))
+	if !errors.Is(err, models.ErrConflict) || conflict.ID != created.ID {
+		t.Errorf("Create() duplicate = %v, %v, want conflict with id %d", conflict, err, created.ID)
+	}
+
+	if _, err = m.Read(ctx, models.SubscriptionIdentifier{ID: 42}); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("Read() missing error = %v, want not found", err)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	m, ctx, user := NewMemory(), tenantContext(models.DefaultTenant), uuid.New()
+	first, _ := m.Create(ctx, subscription(user, "Yandex Plus", 400))
+	second, _ := m.Create(ctx, subscription(user, "Kinopoisk", 300))
+
+	tests := []struct {
+		name    string
+		update  models.Subscription
+		wantErr error
+	}{
+		{"missing subscription", models.Subscription{ID: 42, ServiceName: "Okko", UserUUID: user}, models.ErrNotFound},
+		{"stale version", models.Subscription{ID: first.ID, ServiceName: "Yandex Plus", UserUUID: user, Version: 5}, models.ErrPrecondition},
+		{"name of the other subscription", models.Subscription{ID: second.ID, ServiceName: "Yandex Plus", UserUUID: user}, models.ErrConflict},
+		{"matching version", models.Subscription{ID: first.ID, ServiceName: "Yandex Plus", Price: 450, UserUUID: user, Version: 1}, nil},
+		{"any version", models.Subscription{ID: first.ID, ServiceName: "Yandex Plus", Price: 500, UserUUID: user}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := m.Update(ctx, tt.update); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Update() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	got, _ := m.Read(ctx, models.SubscriptionIdentifier{ID: first.ID})
+	if got.Price != 500 || got.Version != 3 {
+		t.Errorf("Read() after updates = price %d version %d, want price 500 version 3", got.Price, got.Version)
+	}
+}
+
+func TestDeleteRestorePurge(t *testing.T) {
+	m, ctx, user := NewMemory(), tenantContext(models.DefaultTenant), uuid.New()
+	created, _ := m.Create(ctx, subscription(user, "Yandex Plus", 400))
+
+	if err := m.Delete(ctx, models.SubscriptionIdentifier{ID: created.ID, Version: 5}); !errors.Is(err, models.ErrPrecondition) {
+		t.Errorf("Delete() stale version error = %v, want precondition failed", err)
+	}
+	if err := m.Delete(ctx, models.SubscriptionIdentifier{ID: created.ID}); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := m.Read(ctx, models.SubscriptionIdentifier{ID: created.ID}); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("Read() deleted error = %v, want not found", err)
+	}
+	trash, _ := m.List(ctx, models.SubscriptionsWithinPeriod{OnlyDeleted: true, Limit: 10})
+	if len(trash) != 1 || trash[0].ID != created.ID {
+		t.Errorf("List() trash = %v, want the deleted subscription", trash)
+	}
+
+	// The subscription can't be restored while the other subscription of the user and service is stored
+	other, _ := m.Create(ctx, subscription(user, "Yandex Plus", 400))
+	if err := m.Restore(ctx, models.SubscriptionIdentifier{ID: created.ID}); !errors.Is(err, models.ErrConflict) {
+		t.Errorf("Restore() error = %v, want conflict", err)
+	}
+	m.Delete(ctx, models.SubscriptionIdentifier{ID: other.ID})
+	if err := m.Restore(ctx, models.SubscriptionIdentifier{ID: created.ID}); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	purged, err := m.Purge(ctx, time.Now().Add(time.Minute))
+	if err != nil || purged != 1 {
+		t.Errorf("Purge() = %d, %v, want 1 purged", purged, err)
+	}
+	if err := m.Restore(ctx, models.SubscriptionIdentifier{ID: other.ID}); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("Restore() purged error = %v, want not found", err)
+	}
+}
+
+func TestList(t *testing.T) {
+	m, ctx := NewMemory(), tenantContext(models.DefaultTenant)
+	alice, bob := uuid.New(), uuid.New()
+	m.Create(ctx, subscription(alice, "Yandex Plus", 400))
+	m.Create(ctx, subscription(alice, "Kinopoisk", 300))
+	m.Create(ctx, subscription(bob, "Yandex Plus", 200))
+
+	tests := []struct {
+		name   string
+		params models.SubscriptionsWithinPeriod
+		want   []int
+	}{
+		{"all", models.SubscriptionsWithinPeriod{Limit: 10}, []int{1, 2, 3}},
+		{"user", models.SubscriptionsWithinPeriod{UserUUIDs: []uuid.UUID{alice}, Limit: 10}, []int{1, 2}},
+		{"service name", models.SubscriptionsWithinPeriod{ServiceName: "yandex", ServiceNameMatch: models.MatchPrefix, IgnoreCase: true, Limit: 10}, []int{1, 3}},
+		{"price", models.SubscriptionsWithinPeriod{PriceMin: 250, PriceMax: 350, Limit: 10}, []int{2}},
+		{"sorted by price", models.SubscriptionsWithinPeriod{Sort: []models.SortKey{{Column: "price"}}, Limit: 10}, []int{3, 2, 1}},
+		{"page", models.SubscriptionsWithinPeriod{Limit: 1, Offset: 1}, []int{2}},
+		{"period before start", models.SubscriptionsWithinPeriod{EndDate: date(2025, time.June), Limit: 10}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subscriptions, err := m.List(ctx, tt.params)
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			var ids []int
+			for _, s := range subscriptions {
+				ids = append(ids, s.ID)
+			}
+			if len(ids) != len(tt.want) {
+				t.Fatalf("List() ids = %v, want %v", ids, tt.want)
+			}
+			for i := range ids {
+				if ids[i] != tt.want[i] {
+					t.Fatalf("List() ids = %v, want %v", ids, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSummary(t *testing.T) {
+	m, ctx, user := NewMemory(), tenantContext(models.DefaultTenant), uuid.New()
+	m.Create(ctx, subscription(user, "Yandex Plus", 400))
+	usd := subscription(user, "Netflix", 10)
+	usd.Currency = "USD"
+	m.Create(ctx, usd)
+
+	summary, err := m.Summary(ctx, models.SubscriptionsWithinPeriod{StartDate: date(2025, time.July), EndDate: date(2025, time.September)})
+	if err != nil {
+		t.Fatalf("Summary() error = %v", err)
+	}
+	want := []models.CurrencyTotal{{Currency: "RUB", Amount: 1, Total: 1200}, {Currency: "USD", Amount: 1, Total: 30}}
+	if summary.Amount != 2 || summary.Months != 3 || len(summary.Totals) != len(want) {
+		t.Fatalf("Summary() = %+v", summary)
+	}
+	for i := range want {
+		if summary.Totals[i] != want[i] {
+			t.Errorf("Summary() totals[%d] = %+v, want %+v", i, summary.Totals[i], want[i])
+		}
+	}
+
+	// The empty summary has no totals
+	empty, err := m.Summary(ctx, models.SubscriptionsWithinPeriod{StartDate: date(2024, time.January), EndDate: date(2024, time.February)})
+	if err != nil || empty.Amount != 0 || len(empty.Totals) != 0 {
+		t.Errorf("Summary() empty = %+v, %v", empty, err)
+	}
+}
+
+func TestTenantIsolation(t *testing.T) {
+	m, user := NewMemory(), uuid.New()
+	finance, sales := tenantContext("finance"), tenantContext("sales")
+
+	created, _ := m.Create(finance, subscription(user, "Yandex Plus", 400))
+	if _, err := m.Create(sales, subscription(user, "Yandex Plus", 400)); err != nil {
+		t.Errorf("Create() in other tenant error = %v", err)
+	}
+	if _, err := m.Read(sales, models.SubscriptionIdentifier{ID: created.ID}); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("Read() of other tenant error = %v, want not found", err)
+	}
+	if err := m.Delete(sales, models.SubscriptionIdentifier{ID: created.ID}); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("Delete() of other tenant error = %v, want not found", err)
+	}
+	if count, _ := m.Count(sales, models.SubscriptionsWithinPeriod{}); count != 1 {
+		t.Errorf("Count() = %d, want 1", count)
+	}
+
+	// The trash is purged per tenant
+	m.Delete(finance, models.SubscriptionIdentifier{ID: created.ID})
+	if tenants, _ := m.TrashTenants(sales, time.Now().Add(time.Minute)); !slices.Equal(tenants, []string{"finance"}) {
+		t.Errorf("TrashTenants() = %v, want [finance]", tenants)
+	}
+	if purged, err := m.Purge(sales, time.Now().Add(time.Minute)); err != nil || purged != 0 {
+		t.Errorf("Purge() of other tenant = %d, %v, want 0 purged", purged, err)
+	}
+	if purged, err := m.Purge(finance, time.Now().Add(time.Minute)); err != nil || purged != 1 {
+		t.Errorf("Purge() = %d, %v, want 1 purged", purged, err)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	m, user := NewMemory(), uuid.New()
+	ctx := models.WithActor(tenantContext(models.DefaultTenant), "tester")
+	created, _ := m.Create(ctx, subscription(user, "Yandex Plus", 400))
+	m.Update(ctx, models.Subscription{ID: created.ID, ServiceName: "Yandex Plus", Price: 500, UserUUID: user})
+	m.Delete(ctx, models.SubscriptionIdentifier{ID: created.ID})
+
+	events, err := m.History(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	actions := []string{models.EventCreate, models.EventUpdate, models.EventDelete}
+	if len(events) != len(actions) {
+		t.Fatalf("History() = %d events, want %d", len(events), len(actions))
+	}
+	for i, event := range events {
+		if event.Action != actions[i] || event.Actor != "tester" {
+			t.Errorf("History()[%d] = %s by %s, want %s by tester", i, event.Action, event.Actor, actions[i])
+		}
+	}
+	if events[1].Before.Price != 400 || events[1].After.Price != 500 {
+		t.Errorf("History() update = %d -> %d, want 400 -> 500", events[1].Before.Price, events[1].After.Price)
+	}
+
+	if _, err = m.History(ctx, 42); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("History() missing error = %v, want not found", err)
+	}
+}
+
+func TestSchedulePriceHistory(t *testing.T) {
+	m, ctx, user := NewMemory(), tenantContext(models.DefaultTenant), uuid.New()
+	created, _ := m.Create(ctx, subscription(user, "Yandex Plus", 400))
+
+	if err := m.SchedulePrice(ctx, created.ID, models.PriceChange{Price: 500, EffectiveFrom: date(2025, time.September)}); err != nil {
+		t.Fatalf("SchedulePrice() error = %v", err)
+	}
+	events, _ := m.History(ctx, created.ID)
+	if len(events) != 2 {
+		t.Fatalf("History() = %d events, want 2", len(events))
+	}
+	if event := events[1]; event.Action != models.EventSchedulePrice || event.Price == nil || event.Price.Price != 500 || event.After.Price != 400 {
+		t.Errorf("History()[1] = %+v, want scheduled price 500", event)
+	}
+
+	if err := m.SchedulePrice(tenantContext("sales"), created.ID, models.PriceChange{Price: 600, EffectiveFrom: date(2025, time.October)}); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("SchedulePrice() of other tenant error = %v, want not found", err)
+	}
+}
+
+func TestCreateServiceLinks(t *testing.T) {
+	m, ctx, user, other := NewMemory(), tenantContext(models.DefaultTenant), uuid.New(), uuid.New()
+	lower, _ := m.Create(ctx, subscription(user, "yandex  plus", 400))
+	upper, _ := m.Create(ctx, subscription(other, "Yandex Plus", 400))
+	duplicate, _ := m.Create(ctx, subscription(other, "YANDEX PLUS", 400))
+	unrelated, _ := m.Create(ctx, subscription(user, "Kinopoisk", 300))
+	foreign, _ := m.Create(tenantContext("finance"), subscription(user, "yandex plus", 400))
+
+	created, linked, err := m.CreateService(ctx, models.CatalogService{Name: "Yandex Plus", Currency: "RUB"})
+	if err != nil {
+		t.Fatalf("CreateService() error = %v", err)
+	}
+	if len(linked) != 2 || linked[0] != lower.ID || linked[1] != upper.ID {
+		t.Errorf("CreateService() linked = %v, want [%d %d]", linked, lower.ID, upper.ID)
+	}
+
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		id          int
+		wantName    string
+		wantService int
+	}{
+		{"other case", ctx, lower.ID, "Yandex Plus", created.ID},
+		{"canonical name", ctx, upper.ID, "Yandex Plus", created.ID},
+		{"conflicting with the canonical name", ctx, duplicate.ID, "YANDEX PLUS", 0},
+		{"other service", ctx, unrelated.ID, "Kinopoisk", 0},
+		{"other tenant", tenantContext("finance"), foreign.ID, "yandex plus", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.Read(tt.ctx, models.SubscriptionIdentifier{ID: tt.id})
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			if got.ServiceName != tt.wantName || got.ServiceID != tt.wantService {
+				t.Errorf("Read() = %q of service %d, want %q of service %d", got.ServiceName, got.ServiceID, tt.wantName, tt.wantService)
+			}
+		})
+	}
+}
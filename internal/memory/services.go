@@ -0,0 +1,185 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/middelmatigheid/subscriptions-api/internal/models"
+)
+
+// CreateService stores new service of the tenant's catalog and returns its id, if the insertion was successful, or returns id of the service already using
+// any of its names
+func (m *Memory) CreateService(ctx context.Context, service models.CatalogService) (models.IDResponse, []int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Checking if the names are already used
+	service.TenantID = models.TenantFromContext(ctx)
+	if id := m.serviceUsingNames(service); id != 0 {
+		return models.IDResponse{ID: id}, nil, models.NewErrConflict()
+	}
+
+	// Storing the service
+	m.lastServiceID++
+	service.ID = m.lastServiceID
+	service.CreatedAt = models.CustomTime{}
+	service.CreatedAt.Time, service.CreatedAt.Valid = time.Now(), true
+	service.UpdatedAt = service.CreatedAt
+	service.Aliases = slices.Clone(service.Aliases)
+	m.services[service.ID] = service
+	m.storeServiceNames(service)
+	return models.IDResponse{ID: service.ID}, m.linkSubscriptions(ctx, service), nil
+}
+
+// ReadService returns the service of the tenant's catalog specified by its id
+func (m *Memory) ReadService(ctx context.Context, id int) (models.CatalogService, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	service, ok := m.services[id]
+	if !ok || service.TenantID != models.TenantFromContext(ctx) {
+		return models.CatalogService{}, models.NewErrNotFound()
+	}
+	service.Aliases = slices.Clone(service.Aliases)
+	return service, nil
+}
+
+// ResolveService returns the service of the tenant's catalog which name or alias matches the name ignoring case and spaces
+func (m *Memory) ResolveService(ctx context.Context, name string) (models.CatalogService, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	id, ok := m.serviceNames[tenantKey{models.TenantFromContext(ctx), models.NormalizeServiceName(name)}]
+	if !ok {
+		return models.CatalogService{}, models.NewErrNotFound()
+	}
+	service := m.services[id]
+	service.Aliases = slices.Clone(service.Aliases)
+	return service, nil
+}
+
+// UpdateService updates the service of the tenant's catalog specified by its id, links the subscriptions named by its new names and renames the
+// subscriptions referencing it. The names used by another service conflict. Ids of the linked and renamed subscriptions are returned
+func (m *Memory) UpdateService(ctx context.Context, service models.CatalogService) ([]int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.services[service.ID]
+	if !ok || stored.TenantID != models.TenantFromContext(ctx) {
+		return nil, models.NewErrNotFound()
+	}
+	service.TenantID = stored.TenantID
+	if id := m.serviceUsingNames(service); id != 0 && id != service.ID {
+		return nil, models.NewErrConflict()
+	}
+
+	// Renaming the subscriptions, nothing is renamed if any of them conflicts
+	subscriptions, events := maps.Clone(m.subscriptions), len(m.events)
+	var renamed []int
+	for _, subscription := range m.sorted() {
+		if subscription.ServiceID != service.ID || subscription.ServiceName == service.Name || subscription.DeletedAt.Valid {
+			continue
+		}
+		subscription.ServiceName = service.Name
+		if err := m.update(ctx, subscription); err != nil {
+			m.subscriptions, m.events = subscriptions, m.events[:events]
+			return nil, err
+		}
+		renamed = append(renamed, subscription.ID)
+	}
+
+	// Updating the service
+	service.CreatedAt = stored.CreatedAt
+	service.UpdatedAt = models.CustomTime{}
+	service.UpdatedAt.Time, service.UpdatedAt.Valid = time.Now(), true
+	service.Aliases = slices.Clone(service.Aliases)
+	m.services[service.ID] = service
+	m.storeServiceNames(service)
+	return append(renamed, m.linkSubscriptions(ctx, service)...), nil
+}
+
+// DeleteService deletes the service from the tenant's catalog. The service referenced by any subscription including deleted ones can't be deleted
+func (m *Memory) DeleteService(ctx context.Context, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if service, ok := m.services[id]; !ok || service.TenantID != models.TenantFromContext(ctx) {
+		return models.NewErrNotFound()
+	}
+	for _, subscription := range m.subscriptions {
+		if subscription.ServiceID == id {
+			return models.NewErrConflict()
+		}
+	}
+	delete(m.services, id)
+	maps.DeleteFunc(m.serviceNames, func(name tenantKey, service int) bool {
+		return service == id
+	})
+	return nil
+}
+
+// ListServices returns the services of the tenant's catalog ordered by name. The services can be filtered by category
+func (m *Memory) ListServices(ctx context.Context, category string) ([]models.CatalogService, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	services, tenant := []models.CatalogService{}, models.TenantFromContext(ctx)
+	for _, service := range m.services {
+		if service.TenantID == tenant && (category == "" || service.Category == category) {
+			service.Aliases = slices.Clone(service.Aliases)
+			services = append(services, service)
+		}
+	}
+	slices.SortFunc(services, func(a, b models.CatalogService) int {
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return a.ID - b.ID
+	})
+	return services, nil
+}
+
+// Getting id of the service of the same tenant using any of the names of the service, the other services go first. Zero is returned if the names are free. The lock
+// should be held by the caller
+func (m *Memory) serviceUsingNames(service models.CatalogService) int {
+	used := 0
+	for _, name := range service.Names() {
+		if id, ok := m.serviceNames[tenantKey{service.TenantID, name}]; ok && id != service.ID {
+			return id
+		} else if ok {
+			used = id
+		}
+	}
+	return used
+}
+
+// Storing the normalized names of the service the subscriptions are being resolved by. The lock should be held by the caller
+func (m *Memory) storeServiceNames(service models.CatalogService) {
+	maps.DeleteFunc(m.serviceNames, func(name tenantKey, id int) bool {
+		return id == service.ID
+	})
+	for _, name := range service.Names() {
+		m.serviceNames[tenantKey{service.TenantID, name}] = service.ID
+	}
+}
+
+// Linking the tenant's subscriptions which don't reference any service and are named by any of the names of the service ignoring case and spaces.
+// The subscriptions are renamed to the canonical name, the ones conflicting with the subscription of the same user already named so are kept as
+// they are. Ids of the linked subscriptions are returned. The lock should be held by the caller
+func (m *Memory) linkSubscriptions(ctx context.Context, service models.CatalogService) []int {
+	var linked []int
+	for _, subscription := range m.sorted() {
+		if subscription.TenantID != service.TenantID || subscription.ServiceID != 0 || subscription.DeletedAt.Valid ||
+			!slices.Contains(service.Names(), models.NormalizeServiceName(subscription.ServiceName)) {
+			continue
+		}
+		subscription.ServiceName, subscription.ServiceID = service.Name, service.ID
+		if err := m.update(ctx, subscription); err == nil {
+			linked = append(linked, subscription.ID)
+		}
+	}
+	return linked
+}
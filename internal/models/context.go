@@ -0,0 +1,70 @@
+package models
+
+import "context"
+
+type contextKey string
+
+const (
+	actorKey      contextKey = "actor"
+	actorLabelKey contextKey = "actor_label"
+	requestIDKey  contextKey = "request_id"
+	credentialKey contextKey = "credential"
+)
+
+// WithActor returns the context carrying who makes the changes
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+// ActorFromContext returns who makes the changes, empty actor is returned if it is unknown
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey).(string)
+	return actor
+}
+
+// WithActorLabel returns the context carrying the label the client gave itself, the label isn't authenticated
+func WithActorLabel(ctx context.Context, label string) context.Context {
+	return context.WithValue(ctx, actorLabelKey, label)
+}
+
+// ActorLabelFromContext returns the label the client gave itself, empty label is returned if it isn't provided
+func ActorLabelFromContext(ctx context.Context) string {
+	label, _ := ctx.Value(actorLabelKey).(string)
+	return label
+}
+
+// WithRequestID returns the context carrying id of the request
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestIDFromContext returns id of the request, empty id is returned if it is unknown
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// WithCredential returns the context carrying credential of the client
+func WithCredential(ctx context.Context, credential Credential) context.Context {
+	return context.WithValue(ctx, credentialKey, credential)
+}
+
+// CredentialFromContext returns credential of the client, false is returned if the client is not authenticated
+func CredentialFromContext(ctx context.Context) (Credential, bool) {
+	credential, ok := ctx.Value(credentialKey).(Credential)
+	return credential, ok
+}
+
+// SubjectFromContext returns subject of the client, empty subject is returned if the client is not authenticated
+func SubjectFromContext(ctx context.Context) string {
+	credential, _ := CredentialFromContext(ctx)
+	return credential.Subject
+}
+
+// TenantFromContext returns tenant of the client, the default tenant is returned if the client is not authenticated
+func TenantFromContext(ctx context.Context) string {
+	if credential, ok := CredentialFromContext(ctx); ok && credential.TenantID != "" {
+		return credential.TenantID
+	}
+	return DefaultTenant
+}
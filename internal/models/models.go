@@ -4,8 +4,14 @@ import (
 	"context"
 	"database/sql"
 	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,13 +19,54 @@ import (
 
 type Storage interface {
 	Close() error
+	IdempotencyStorage
+	CatalogStorage
+	AuthStorage
 
 	Create(context.Context, Subscription) (IDResponse, error)
 	Read(context.Context, SubscriptionIdentifier) (Subscription, error)
 	Update(context.Context, Subscription) error
 	Delete(context.Context, SubscriptionIdentifier) error
 	List(context.Context, SubscriptionsWithinPeriod) ([]Subscription, error)
+	Count(context.Context, SubscriptionsWithinPeriod) (int, error)
 	Summary(context.Context, SubscriptionsWithinPeriod) (SummaryResponse, error)
+	Timeseries(context.Context, SubscriptionsWithinPeriod) ([]TimeseriesBucket, error)
+	Restore(context.Context, SubscriptionIdentifier) error
+	Purge(context.Context, time.Time) (int, error)
+	TrashTenants(context.Context, time.Time) ([]string, error)
+	History(context.Context, int) ([]SubscriptionEvent, error)
+	SchedulePrice(context.Context, int, PriceChange) error
+	Prices(context.Context, int) ([]PriceChange, error)
+	Bulk(context.Context, []BulkOperation, bool) ([]BulkResult, error)
+	Export(context.Context, SubscriptionsWithinPeriod, func(Subscription) error) error
+}
+
+// CatalogStorage keeps the services the subscriptions are referencing. The services are being resolved by their names and aliases
+type CatalogStorage interface {
+	CreateService(context.Context, CatalogService) (IDResponse, []int, error)
+	ReadService(context.Context, int) (CatalogService, error)
+	ResolveService(context.Context, string) (CatalogService, error)
+	UpdateService(context.Context, CatalogService) ([]int, error)
+	DeleteService(context.Context, int) error
+	ListServices(context.Context, string) ([]CatalogService, error)
+}
+
+// AuthStorage keeps the api keys of the clients. Only hashes of the keys are being stored
+type AuthStorage interface {
+	CreateAPIKey(context.Context, APIKey) (IDResponse, error)
+	ReadAPIKey(context.Context, string) (APIKey, error)
+	ListAPIKeys(context.Context) ([]APIKey, error)
+	RevokeAPIKey(context.Context, int) error
+	TouchAPIKey(context.Context, int, time.Time) error
+}
+
+// IdempotencyStorage keeps responses of the requests made with idempotency keys so the retried requests can be replayed. The expired keys are
+// being purged periodically
+type IdempotencyStorage interface {
+	ReserveIdempotencyKey(context.Context, IdempotencyRecord, time.Time) (IdempotencyRecord, error)
+	SaveIdempotencyResponse(context.Context, IdempotencyRecord) error
+	ReleaseIdempotencyKey(context.Context, string) error
+	PurgeIdempotencyKeys(context.Context, time.Time) (int, error)
 }
 
 type SubscriptionService interface {
@@ -28,8 +75,30 @@ type SubscriptionService interface {
 	Update(context.Context, Subscription) error
 	Patch(context.Context, SubscriptionPatch) error
 	Delete(context.Context, SubscriptionIdentifier) error
-	List(context.Context, SubscriptionsWithinPeriod) ([]Subscription, error)
+	List(context.Context, SubscriptionsWithinPeriod) (ListResponse, error)
 	Summary(context.Context, SubscriptionsWithinPeriod) (SummaryResponse, error)
+	Timeseries(context.Context, SubscriptionsWithinPeriod) (TimeseriesResponse, error)
+	Restore(context.Context, SubscriptionIdentifier) error
+	Purge(context.Context, time.Time) (int, error)
+	History(context.Context, int) ([]SubscriptionEvent, error)
+	SchedulePrice(context.Context, int, PriceChange) error
+	Prices(context.Context, int) ([]PriceChange, error)
+	Bulk(context.Context, BulkRequest) ([]BulkResult, error)
+	Export(context.Context, SubscriptionsWithinPeriod, func(Subscription) error) error
+	Import(context.Context, []ImportRow, bool) (ImportResult, error)
+
+	CreateService(context.Context, CatalogService) (IDResponse, error)
+	ReadService(context.Context, int) (CatalogService, error)
+	UpdateService(context.Context, CatalogService) error
+	DeleteService(context.Context, int) error
+	ListServices(context.Context, string) ([]CatalogService, error)
+
+	IssueAPIKey(context.Context, APIKey) (APIKey, error)
+	ListAPIKeys(context.Context) ([]APIKey, error)
+	RevokeAPIKey(context.Context, int) error
+
+	Rates(context.Context) (ExchangeRates, error)
+	SetRates(context.Context, ExchangeRates) error
 }
 
 // Custom date to deal with right format and null fields
@@ -73,7 +142,7 @@ func (cd *CustomDate) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
-func (cd *CustomDate) MarshalJSON() ([]byte, error) {
+func (cd CustomDate) MarshalJSON() ([]byte, error) {
 	if cd.Valid {
 		return []byte(fmt.Sprintf(`"%s"`, cd.Time.Format("01-2006"))), nil
 	}
@@ -128,50 +197,629 @@ func (ct CustomTime) MarshalJSON() ([]byte, error) {
 	return []byte("null"), nil
 }
 
+// Billing units. The price is being charged once per billing interval of units starting from the subscription's start date
+const (
+	BillingWeek    = "week"
+	BillingMonth   = "month"
+	BillingQuarter = "quarter"
+	BillingYear    = "year"
+)
+
+// On update the version is being compared with the stored one, zero version matches any. The version is being incremented on each change.
+// Deleted subscriptions are kept in the trash until they are restored or purged. The subscription belongs to the tenant of the client which created it
 type Subscription struct {
-	ID          int        `json:"id" example:"1"`
-	ServiceName string     `json:"service_name" example:"Yandex Plus"`
-	Price       int        `json:"price" example:"400"`
-	UserUUID    uuid.UUID  `json:"user_uuid" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
-	StartDate   CustomDate `json:"start_date" example:"07-2025" swaggertype:"string"`
-	EndDate     CustomDate `json:"end_date" example:"08-2025" swaggertype:"string"`
-	CreatedAt   CustomTime `json:"created_at" example:"01-07-2025 14:00" swaggerignore:"true"`
-	UpdatedAt   CustomTime `json:"updated_at" example:"01-07-2025 14:00" swaggerignore:"true"`
+	ID              int        `json:"id" example:"1"`
+	ServiceName     string     `json:"service_name" example:"Yandex Plus"`
+	Price           int        `json:"price" example:"400"`
+	Currency        string     `json:"currency" example:"RUB"`
+	BillingUnit     string     `json:"billing_unit" example:"month" enums:"week,month,quarter,year"`
+	BillingInterval int        `json:"billing_interval" example:"1"`
+	UserUUID        uuid.UUID  `json:"user_uuid" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	StartDate       CustomDate `json:"start_date" example:"07-2025" swaggertype:"string"`
+	EndDate         CustomDate `json:"end_date" example:"08-2025" swaggertype:"string"`
+	Version         int        `json:"version" example:"1" swaggerignore:"true"`
+	CreatedAt       CustomTime `json:"created_at" example:"01-07-2025 14:00" swaggerignore:"true"`
+	UpdatedAt       CustomTime `json:"updated_at" example:"01-07-2025 14:00" swaggerignore:"true"`
+	DeletedAt       CustomTime `json:"deleted_at" example:"01-07-2025 14:00" swaggerignore:"true"`
+	ServiceID       int        `json:"service_id,omitempty" example:"1" swaggerignore:"true"`
+	Category        string     `json:"category" example:"music"`
+	Tags            []string   `json:"tags" example:"streaming,family"`
+	TenantID        string     `json:"-"`
+}
+
+// BillingDates returns the dates within the period when the subscription's price is being charged. Both of the period's months are included
+func (s Subscription) BillingDates(start, end time.Time) []time.Time {
+	// The subscription is being paid until the last day of its end month
+	last := end.AddDate(0, 1, -1)
+	if s.EndDate.Valid && s.EndDate.Time.AddDate(0, 1, -1).Before(last) {
+		last = s.EndDate.Time.AddDate(0, 1, -1)
+	}
+
+	interval := max(s.BillingInterval, 1)
+	var dates []time.Time
+	for i := 0; ; i++ {
+		var date time.Time
+		switch s.BillingUnit {
+		case BillingWeek:
+			date = s.StartDate.Time.AddDate(0, 0, 7*interval*i)
+		case BillingQuarter:
+			date = s.StartDate.Time.AddDate(0, 3*interval*i, 0)
+		case BillingYear:
+			date = s.StartDate.Time.AddDate(interval*i, 0, 0)
+		default:
+			date = s.StartDate.Time.AddDate(0, interval*i, 0)
+		}
+		if date.After(last) {
+			return dates
+		}
+		if !date.Before(start) {
+			dates = append(dates, date)
+		}
+	}
+}
+
+// Service of the catalog. Service name of the subscription is being replaced by the canonical name if it matches the name or any of the aliases
+// ignoring case and spaces, the default price and currency are used if the subscription doesn't provide them
+type CatalogService struct {
+	ID           int        `json:"id" example:"1"`
+	Name         string     `json:"name" example:"Yandex Plus"`
+	Aliases      []string   `json:"aliases" example:"yandex plus,Яндекс Плюс"`
+	Category     string     `json:"category" example:"music"`
+	DefaultPrice int        `json:"default_price" example:"400"`
+	Currency     string     `json:"currency" example:"RUB"`
+	CreatedAt    CustomTime `json:"created_at" example:"01-07-2025 14:00" swaggerignore:"true"`
+	UpdatedAt    CustomTime `json:"updated_at" example:"01-07-2025 14:00" swaggerignore:"true"`
+	TenantID     string     `json:"-"`
+}
+
+// Names returns the canonical name and the aliases of the service normalized for the resolution
+func (cs CatalogService) Names() []string {
+	names := []string{NormalizeServiceName(cs.Name)}
+	for _, alias := range cs.Aliases {
+		if name := NormalizeServiceName(alias); !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// NormalizeServiceName returns the name in lower case with single spaces between the words
+func NormalizeServiceName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
 }
 
 // The pointers is being used to identify them from invalid empty request because in the patch endpoint some fields can be not provided
 type SubscriptionPatch struct {
-	ID          int         `json:"id" example:"1"`
-	ServiceName *string     `json:"service_name" example:"Yandex Plus"`
-	Price       *int        `json:"price" example:"400"`
-	UserUUID    *uuid.UUID  `json:"user_uuid" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
-	StartDate   *CustomDate `json:"start_date" example:"07-2025" swaggertype:"string"`
-	EndDate     *CustomDate `json:"end_date" example:"08-2025" swaggertype:"string"`
+	ID              int         `json:"id" example:"1"`
+	ServiceName     *string     `json:"service_name" example:"Yandex Plus"`
+	Price           *int        `json:"price" example:"400"`
+	Currency        *string     `json:"currency" example:"RUB"`
+	BillingUnit     *string     `json:"billing_unit" example:"month" enums:"week,month,quarter,year"`
+	BillingInterval *int        `json:"billing_interval" example:"1"`
+	UserUUID        *uuid.UUID  `json:"user_uuid" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	StartDate       *CustomDate `json:"start_date" example:"07-2025" swaggertype:"string"`
+	EndDate         *CustomDate `json:"end_date" example:"08-2025" swaggertype:"string"`
+	Category        *string     `json:"category" example:"music"`
+	Tags            *[]string   `json:"tags" example:"streaming,family"`
+	Version         int         `json:"-"`
 }
 
 type IDResponse struct {
 	ID int `json:"id" example:"1"`
 }
 
+type PurgeResponse struct {
+	Purged int `json:"purged" example:"3"`
+}
+
+// Price of the subscription effective from the month until the next change. The subscription's own price is effective from its start date
+type PriceChange struct {
+	Price         int        `json:"price" example:"500"`
+	EffectiveFrom CustomDate `json:"effective_from" example:"09-2025" swaggertype:"string"`
+	CreatedAt     CustomTime `json:"created_at" example:"01-07-2025 14:00" swaggerignore:"true"`
+}
+
+// PriceAt returns the price of the subscription charged at the date. The changes should be ordered by the month they are effective from
+func (s Subscription) PriceAt(changes []PriceChange, date time.Time) int {
+	price := s.Price
+	for _, change := range changes {
+		if change.EffectiveFrom.Time.After(date) {
+			break
+		}
+		price = change.Price
+	}
+	return price
+}
+
+// Response to the request made with the idempotency key. The status is zero while the request is being processed
+type IdempotencyRecord struct {
+	Key         string
+	RequestHash string
+	Status      int
+	Headers     map[string]string
+	Body        []byte
+	CreatedAt   time.Time
+}
+
+// Operations of the bulk request
+const (
+	BulkCreate = "create"
+	BulkUpdate = "update"
+	BulkDelete = "delete"
+)
+
+// Modes of the bulk request. In all-or-nothing mode the operations are executed in one transaction, in best-effort mode each operation is executed
+// independently
+const (
+	BulkAllOrNothing = "all_or_nothing"
+	BulkBestEffort   = "best_effort"
+)
+
+// Maximum amount of operations in one bulk request
+const BulkLimit = 1000
+
+type BulkRequest struct {
+	Mode       string          `json:"mode" example:"all_or_nothing"`
+	Operations []BulkOperation `json:"operations"`
+}
+
+// Operation of the bulk request. The subscription is provided to create and update, id and version are provided to delete. The version of the
+// subscription being updated is being provided inside the subscription
+type BulkOperation struct {
+	Op           string       `json:"op" example:"create"`
+	Subscription Subscription `json:"subscription"`
+	ID           int          `json:"id" example:"1"`
+	Version      int          `json:"version" example:"0"`
+}
+
+// Result of the bulk operation. Id of the created subscription or the conflicting one is returned
+type BulkResult struct {
+	Index  int    `json:"index" example:"0"`
+	Op     string `json:"op" example:"create"`
+	ID     int    `json:"id,omitempty" example:"1"`
+	Status int    `json:"status" example:"201"`
+	Msg    string `json:"msg" example:"The subscription successfully created"`
+	Error  string `json:"error,omitempty"`
+	Err    error  `json:"-"`
+}
+
+// Subscription read from the line of the imported file. The error is set if the line can't be parsed
+type ImportRow struct {
+	Line         int
+	Subscription Subscription
+	Err          error
+}
+
+type ImportError struct {
+	Line  int    `json:"line" example:"2"`
+	Error string `json:"error" example:"Bad request: Invalid price"`
+}
+
+// Result of the import. In dry-run mode the subscriptions are only being validated
+type ImportResult struct {
+	DryRun   bool          `json:"dry_run" example:"false"`
+	Rows     int           `json:"rows" example:"10"`
+	Imported int           `json:"imported" example:"10"`
+	Errors   []ImportError `json:"errors"`
+}
+
+// Actions changing the subscription
+const (
+	EventCreate        = "create"
+	EventUpdate        = "update"
+	EventDelete        = "delete"
+	EventRestore       = "restore"
+	EventPurge         = "purge"
+	EventSchedulePrice = "schedule_price"
+)
+
+// Change of the subscription with its states before and after the change. The state is null before the creation and after the purge. Scheduling
+// the price keeps the state and carries the scheduled price
+type SubscriptionEvent struct {
+	ID             int           `json:"id" example:"1"`
+	SubscriptionID int           `json:"subscription_id" example:"1"`
+	Action         string        `json:"action" example:"update"`
+	Before         *Subscription `json:"before"`
+	After          *Subscription `json:"after"`
+	Price          *PriceChange  `json:"price,omitempty"`
+	Actor          string        `json:"actor" example:"api key admin"`
+	ActorLabel     string        `json:"actor_label" example:"billing-sync"`
+	RequestID      string        `json:"request_id" example:"0f8fad5b-d9cb-469f-a165-70867728950e"`
+	CreatedAt      CustomTime    `json:"created_at" swaggertype:"string" example:"01-07-2025 14:00"`
+	TenantID       string        `json:"-"`
+}
+
+// The version is being compared with the stored one before the subscription is changed, zero version matches any
 type SubscriptionIdentifier struct {
 	ID          int
 	ServiceName string
 	UserUUID    uuid.UUID
+	Version     int
 }
 
+// Matches of the service name
+const (
+	MatchExact    = "exact"
+	MatchPrefix   = "prefix"
+	MatchContains = "contains"
+)
+
 type SubscriptionsWithinPeriod struct {
-	ServiceName string     `json:"service_name"`
-	UserUUID    uuid.UUID  `json:"user_uuid"`
-	StartDate   CustomDate `json:"start_date"`
-	EndDate     CustomDate `json:"end_date"`
-	Limit       int        `json:"limit"`
-	Offset      int        `json:"offset"`
+	ServiceName string `json:"service_name"`
+	// Service name is matched exactly unless the other match is requested
+	ServiceNameMatch string      `json:"service_name_match"`
+	IgnoreCase       bool        `json:"ignore_case"`
+	UserUUIDs        []uuid.UUID `json:"user_uuids"`
+	StartDate        CustomDate  `json:"start_date"`
+	EndDate          CustomDate  `json:"end_date"`
+	// Filters are applied only if they are provided, subscriptions having all the tags are matched
+	PriceMin     int        `json:"price_min"`
+	PriceMax     int        `json:"price_max"`
+	ActiveOn     CustomDate `json:"active_on"`
+	HasEndDate   *bool      `json:"has_end_date"`
+	CreatedAfter CustomTime `json:"created_after"`
+	UpdatedAfter CustomTime `json:"updated_after"`
+	Category     string     `json:"category"`
+	Tags         []string   `json:"tags"`
+	Limit        int        `json:"limit"`
+	Offset       int        `json:"offset"`
+	Currency     string     `json:"currency"`
+	GroupBy      []string   `json:"group_by"`
+	Bucket       string     `json:"bucket"`
+	// Deleted subscriptions are excluded unless they are included or only they are requested
+	IncludeDeleted bool `json:"include_deleted"`
+	OnlyDeleted    bool `json:"only_deleted"`
+	// The list is sorted by the columns in order, id is the last one
+	Sort []SortKey `json:"sort"`
+	// The page starts next to the cursor instead of the offset if the cursor is provided
+	Cursor *Cursor `json:"cursor"`
+	// Total amount of the subscriptions isn't counted if it is skipped
+	SkipCount bool `json:"skip_count"`
+}
+
+// Columns the list can be sorted by
+var SortColumns = []string{"id", "service_name", "price", "currency", "user_uuid", "start_date", "end_date", "created_at", "updated_at"}
+
+// Column the list is sorted by, the column is sorted in descending order if it is desc
+type SortKey struct {
+	Column string `json:"column"`
+	Desc   bool   `json:"desc"`
 }
 
+// ParseSort parses the columns separated by commas, the columns sorted in descending order are prefixed by minus
+func ParseSort(sort string) ([]SortKey, error) {
+	var keys []SortKey
+	for _, column := range strings.Split(sort, ",") {
+		key := SortKey{Column: strings.TrimSpace(column)}
+		if strings.HasPrefix(key.Column, "-") {
+			key.Column, key.Desc = key.Column[1:], true
+		}
+		if !slices.Contains(SortColumns, key.Column) {
+			return nil, NewErrBadRequest(errors.New("Invalid sort column " + column))
+		}
+		if slices.ContainsFunc(keys, func(k SortKey) bool { return k.Column == key.Column }) {
+			return nil, NewErrBadRequest(errors.New("Duplicated sort column " + key.Column))
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// Order returns the columns the list is sorted by. The list is sorted by id after the other columns, so the order is stable
+func (p SubscriptionsWithinPeriod) Order() []SortKey {
+	for i, key := range p.Sort {
+		if key.Column == "id" {
+			return p.Sort[:i+1]
+		}
+	}
+	return append(slices.Clone(p.Sort), SortKey{Column: "id"})
+}
+
+// Formats of the dates and the timestamps kept in the cursor
+const (
+	cursorDate = "2006-01-02"
+	cursorTime = "2006-01-02T15:04:05.999999999"
+)
+
+// Position in the sorted list the page starts next to. Values of the sort columns besides id are kept in order of the sort, empty end date stands
+// for null. The page is being read backwards to get the previous one
+type Cursor struct {
+	ID       int      `json:"id"`
+	Values   []string `json:"values,omitempty"`
+	Backward bool     `json:"backward,omitempty"`
+}
+
+// NewCursor returns the cursor pointing to the subscription in the list sorted in the order
+func NewCursor(subscription Subscription, order []SortKey, backward bool) Cursor {
+	cursor := Cursor{ID: subscription.ID, Backward: backward}
+	for _, key := range order {
+		var value string
+		switch key.Column {
+		case "id":
+			continue
+		case "service_name":
+			value = subscription.ServiceName
+		case "price":
+			value = strconv.Itoa(subscription.Price)
+		case "currency":
+			value = subscription.Currency
+		case "user_uuid":
+			value = subscription.UserUUID.String()
+		case "start_date":
+			value = subscription.StartDate.Time.Format(cursorDate)
+		case "end_date":
+			if subscription.EndDate.Valid {
+				value = subscription.EndDate.Time.Format(cursorDate)
+			}
+		case "created_at":
+			value = subscription.CreatedAt.Time.UTC().Format(cursorTime)
+		case "updated_at":
+			value = subscription.UpdatedAt.Time.UTC().Format(cursorTime)
+		}
+		cursor.Values = append(cursor.Values, value)
+	}
+	return cursor
+}
+
+// Subscription returns the subscription the cursor points to. Only id and the sort columns are filled, the cursor is invalid if its values don't
+// match the order
+func (c Cursor) Subscription(order []SortKey) (Subscription, error) {
+	invalid := NewErrBadRequest(errors.New("Invalid cursor"))
+	subscription, values := Subscription{ID: c.ID}, c.Values
+	for _, key := range order {
+		if key.Column == "id" {
+			continue
+		}
+		if len(values) == 0 {
+			return Subscription{}, invalid
+		}
+		value := values[0]
+		values = values[1:]
+
+		var err error
+		switch key.Column {
+		case "service_name":
+			subscription.ServiceName = value
+		case "price":
+			subscription.Price, err = strconv.Atoi(value)
+		case "currency":
+			subscription.Currency = value
+		case "user_uuid":
+			subscription.UserUUID, err = uuid.Parse(value)
+		case "start_date":
+			subscription.StartDate.Time, err = time.Parse(cursorDate, value)
+			subscription.StartDate.Valid = true
+		case "end_date":
+			if value != "" {
+				subscription.EndDate.Time, err = time.Parse(cursorDate, value)
+				subscription.EndDate.Valid = true
+			}
+		case "created_at":
+			subscription.CreatedAt.Time, err = time.Parse(cursorTime, value)
+			subscription.CreatedAt.Valid = true
+		case "updated_at":
+			subscription.UpdatedAt.Time, err = time.Parse(cursorTime, value)
+			subscription.UpdatedAt.Valid = true
+		}
+		if err != nil {
+			return Subscription{}, invalid
+		}
+	}
+	if len(values) > 0 {
+		return Subscription{}, invalid
+	}
+	return subscription, nil
+}
+
+// Encode returns opaque token of the cursor
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses the cursor from its token
+func DecodeCursor(token string) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return Cursor{}, NewErrBadRequest(errors.New("Invalid cursor"))
+	}
+	var cursor Cursor
+	if err = json.Unmarshal(data, &cursor); err != nil || cursor.ID <= 0 {
+		return Cursor{}, NewErrBadRequest(errors.New("Invalid cursor"))
+	}
+	return cursor, nil
+}
+
+// Page of the subscriptions with the tokens of the cursors to the next and previous pages. Empty token means there is no such page. Total amount
+// of the subscriptions is null if the count was skipped
+type ListResponse struct {
+	Subscriptions []Subscription `json:"body"`
+	Total         *int           `json:"total" example:"42"`
+	Limit         int            `json:"limit" example:"10"`
+	Offset        int            `json:"offset" example:"0"`
+	NextCursor    string         `json:"next_cursor" example:"eyJpZCI6MTB9"`
+	PrevCursor    string         `json:"prev_cursor" example:"eyJpZCI6MSwiYmFja3dhcmQiOnRydWV9"`
+	Links         ListLinks      `json:"links"`
+}
+
+// Links to the next and previous pages, the links are null if there are no such pages
+type ListLinks struct {
+	Next *string `json:"next" example:"/api/v1/subscriptions?cursor=eyJpZCI6MTB9&limit=10"`
+	Prev *string `json:"prev" example:"/api/v1/subscriptions?cursor=eyJpZCI6MSwiYmFja3dhcmQiOnRydWV9&limit=10"`
+}
+
+// Fields the summary can be grouped by. The subscription is counted in the group of each of its tags, the subscriptions without tags are in the
+// group of empty tag
+const (
+	GroupByServiceName = "service_name"
+	GroupByUserUUID    = "user_uuid"
+	GroupByMonth       = "month"
+	GroupByCategory    = "category"
+	GroupByTag         = "tag"
+)
+
+// Months returns amount of months between two dates including both of them
+func Months(start, end time.Time) int {
+	return (end.Year()-start.Year())*12 + int(end.Month()) - int(start.Month()) + 1
+}
+
+// The total is being converted into the currency, rates used for conversion of the totals in other currencies are provided
 type SummaryResponse struct {
-	Amount int `json:"amount" example:"1"`
-	Months int `json:"months" example:"2"`
-	Total  int `json:"total" example:"400"`
+	Amount   int                `json:"amount" example:"1"`
+	Months   int                `json:"months" example:"2"`
+	Total    float64            `json:"total" example:"400"`
+	Currency string             `json:"currency" example:"RUB"`
+	Rates    map[string]float64 `json:"rates,omitempty"`
+	Totals   []CurrencyTotal    `json:"totals"`
+	Groups   []SummaryGroup     `json:"groups,omitempty"`
+}
+
+// Summary of the subscriptions within the group. The group is specified by values of the fields it is grouped by, month group includes subscriptions
+// active within the month and their charges in the month
+type SummaryGroup struct {
+	Group    map[string]string `json:"group"`
+	Amount   int               `json:"amount" example:"1"`
+	Total    float64           `json:"total" example:"400"`
+	Currency string            `json:"currency" example:"RUB"`
+	Totals   []CurrencyTotal   `json:"totals"`
+}
+
+// Total of the subscriptions in their own currency
+type CurrencyTotal struct {
+	Currency string `json:"currency" example:"RUB"`
+	Amount   int    `json:"amount" example:"1"`
+	Total    int    `json:"total" example:"400"`
+}
+
+// Buckets of the time series
+const (
+	BucketWeek    = "week"
+	BucketMonth   = "month"
+	BucketQuarter = "quarter"
+	BucketYear    = "year"
+)
+
+// BucketStart returns the start of the bucket containing the date. Weeks start on Monday
+func BucketStart(bucket string, date time.Time) time.Time {
+	year, month, day := date.Date()
+	switch bucket {
+	case BucketWeek:
+		return time.Date(year, month, day-(int(date.Weekday())+6)%7, 0, 0, 0, 0, date.Location())
+	case BucketQuarter:
+		return time.Date(year, (month-1)/3*3+1, 1, 0, 0, 0, 0, date.Location())
+	case BucketYear:
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, date.Location())
+	default:
+		return time.Date(year, month, 1, 0, 0, 0, 0, date.Location())
+	}
+}
+
+// BucketNext returns the start of the next bucket
+func BucketNext(bucket string, start time.Time) time.Time {
+	switch bucket {
+	case BucketWeek:
+		return start.AddDate(0, 0, 7)
+	case BucketQuarter:
+		return start.AddDate(0, 3, 0)
+	case BucketYear:
+		return start.AddDate(1, 0, 0)
+	default:
+		return start.AddDate(0, 1, 0)
+	}
+}
+
+// Subscriptions within the bucket of the time series. The bucket is bounded by the requested period, subscriptions active within the bucket, started
+// within the bucket and ended within the bucket are counted. The spend is being converted into the currency
+type TimeseriesBucket struct {
+	Start     string          `json:"start" example:"01-07-2025"`
+	End       string          `json:"end" example:"31-07-2025"`
+	Active    int             `json:"active" example:"2"`
+	New       int             `json:"new" example:"1"`
+	Cancelled int             `json:"cancelled" example:"0"`
+	Spend     float64         `json:"spend" example:"800"`
+	Currency  string          `json:"currency" example:"RUB"`
+	Totals    []CurrencyTotal `json:"totals"`
+}
+
+type TimeseriesResponse struct {
+	Bucket   string             `json:"bucket" example:"month"`
+	Currency string             `json:"currency" example:"RUB"`
+	Rates    map[string]float64 `json:"rates,omitempty"`
+	Buckets  []TimeseriesBucket `json:"buckets"`
+}
+
+// Exchange rates, each rate is the price of one unit of the currency in the base currency
+type ExchangeRates struct {
+	Base  string             `json:"base" example:"RUB"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+// Scopes of the api keys. Read scope allows reading the subscriptions, write scope allows changing them as well, admin scope allows everything
+// including changing the catalog, the rates and the api keys
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+	ScopeAdmin = "admin"
+)
+
+// Roles of the clients. Members manage only the subscriptions of their user, editors manage the subscriptions of all the users, auditors read the
+// subscriptions of all the users without changing them, admins are allowed everything including the bulk operations and purging the trash
+const (
+	RoleAdmin   = "admin"
+	RoleMember  = "member"
+	RoleEditor  = "editor"
+	RoleAuditor = "auditor"
+)
+
+// Api key of the client. The key itself is returned only once when it is issued, the prefix of the key is kept to tell the keys apart. Expired
+// and revoked keys are rejected, the key doesn't expire if the expiration time is not provided
+type APIKey struct {
+	ID         int        `json:"id" example:"1"`
+	Name       string     `json:"name" example:"billing export"`
+	Key        string     `json:"key,omitempty" example:"sk_3f9a1c..."`
+	Prefix     string     `json:"prefix" example:"sk_3f9a1c"`
+	Scopes     []string   `json:"scopes" example:"read,write" enums:"read,write,admin"`
+	ExpiresAt  CustomTime `json:"expires_at" example:"01-07-2026 14:00" swaggertype:"string"`
+	LastUsedAt CustomTime `json:"last_used_at" example:"01-07-2025 14:00" swaggerignore:"true"`
+	CreatedAt  CustomTime `json:"created_at" example:"01-07-2025 14:00" swaggerignore:"true"`
+	RevokedAt  CustomTime `json:"revoked_at" example:"01-07-2025 14:00" swaggerignore:"true"`
+	TenantID   string     `json:"tenant_id" example:"finance" swaggerignore:"true"`
+	Hash       string     `json:"-"`
+}
+
+// Tenant of the clients which credentials don't specify one, the data stored before the tenants were introduced belongs to it
+const DefaultTenant = "default"
+
+// Tenant id consists of lower case latin letters, digits, dashes and underscores
+var tenantID = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// ValidTenant checks if the tenant id is well formed
+func ValidTenant(tenant string) bool {
+	return tenantID.MatchString(tenant)
+}
+
+// Credential of the client making the request. The client reaches only the data of its tenant. The client acting on behalf of the user reaches only
+// the user's subscriptions, the user uuid is nil for the clients reaching all of them. The role defines the operations the client is allowed. The
+// subject tells apart the clients of the tenant, such as the api key or the token's user
+type Credential struct {
+	KeyID    int
+	Name     string
+	Subject  string
+	Scopes   []string
+	UserUUID uuid.UUID
+	TenantID string
+	Role     string
+}
+
+// HasScope checks if the credential is allowed to act within the scope. Admin scope includes write scope, write scope includes read scope
+func (c Credential) HasScope(scope string) bool {
+	for _, granted := range c.Scopes {
+		switch {
+		case granted == scope, granted == ScopeAdmin, granted == ScopeWrite && scope == ScopeRead:
+			return true
+		}
+	}
+	return false
 }
 
 // Custom errors
@@ -180,6 +828,10 @@ var (
 	ErrNotFound       error = errors.New("Not Found")
 	ErrInternalServer error = errors.New("Internal Server Error")
 	ErrBadRequest     error = errors.New("Bad request")
+	ErrPrecondition   error = errors.New("Precondition Failed")
+	ErrAborted        error = errors.New("Aborted")
+	ErrUnauthorized   error = errors.New("Unauthorized")
+	ErrForbidden      error = errors.New("Forbidden")
 )
 
 func NewErrConflict() error {
@@ -190,6 +842,18 @@ func NewErrNotFound() error {
 	return ErrNotFound
 }
 
+func NewErrPrecondition() error {
+	return ErrPrecondition
+}
+
+func NewErrAborted() error {
+	return ErrAborted
+}
+
+func NewErrForbidden() error {
+	return ErrForbidden
+}
+
 func NewErrInternalServer(err error) error {
 	return fmt.Errorf("%w: %w", ErrInternalServer, err)
 }
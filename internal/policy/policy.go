@@ -0,0 +1,318 @@
+package policy
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/middelmatigheid/subscriptions-api/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Operations of the service the rules are declared for
+const (
+	OpCreate        = "create"
+	OpRead          = "read"
+	OpUpdate        = "update"
+	OpPatch         = "patch"
+	OpDelete        = "delete"
+	OpList          = "list"
+	OpSummary       = "summary"
+	OpTimeseries    = "timeseries"
+	OpRestore       = "restore"
+	OpPurge         = "purge"
+	OpHistory       = "history"
+	OpSchedulePrice = "schedule_price"
+	OpPrices        = "prices"
+	OpBulk          = "bulk"
+	OpExport        = "export"
+	OpImport        = "import"
+	OpCreateService = "create_service"
+	OpReadService   = "read_service"
+	OpUpdateService = "update_service"
+	OpDeleteService = "delete_service"
+	OpListServices  = "list_services"
+	OpIssueAPIKey   = "issue_api_key"
+	OpListAPIKeys   = "list_api_keys"
+	OpRevokeAPIKey  = "revoke_api_key"
+	OpRates         = "rates"
+	OpSetRates      = "set_rates"
+)
+
+// Groups of the roles sharing the rules
+var (
+	everyone = []string{models.RoleAdmin, models.RoleMember, models.RoleEditor, models.RoleAuditor}
+	owners   = []string{models.RoleAdmin, models.RoleMember, models.RoleEditor}
+	admins   = []string{models.RoleAdmin}
+)
+
+// Rules lists the roles allowed to perform each operation. Members reach only the subscriptions of their user, the service restricts them to those.
+// Editors change the subscriptions of all the users one by one. Auditors read the subscriptions of all the users without changing them. Bulk
+// operations, import, purging the trash and changing the catalog, the api keys and the rates are left to admins
+var Rules = map[string][]string{
+	OpCreate:        owners,
+	OpRead:          everyone,
+	OpUpdate:        owners,
+	OpPatch:         owners,
+	OpDelete:        owners,
+	OpList:          everyone,
+	OpSummary:       everyone,
+	OpTimeseries:    everyone,
+	OpRestore:       owners,
+	OpPurge:         admins,
+	OpHistory:       everyone,
+	OpSchedulePrice: owners,
+	OpPrices:        everyone,
+	OpBulk:          admins,
+	OpExport:        everyone,
+	OpImport:        admins,
+	OpCreateService: admins,
+	OpReadService:   everyone,
+	OpUpdateService: admins,
+	OpDeleteService: admins,
+	OpListServices:  everyone,
+	OpIssueAPIKey:   admins,
+	OpListAPIKeys:   admins,
+	OpRevokeAPIKey:  admins,
+	OpRates:         everyone,
+	OpSetRates:      admins,
+}
+
+// Policy is a role-based layer between the handlers and the service. The operation is passed to the service only if the role of the client is
+// allowed to perform it, otherwise the forbidden error is returned
+type Policy struct {
+	service models.SubscriptionService
+}
+
+// Creates the policy guarding the service
+func NewPolicy(service models.SubscriptionService) *Policy {
+	return &Policy{service: service}
+}
+
+// Allowed checks if the credential's role is allowed to perform the operation. The member should be bound to the user since the member reaches
+// only the user's subscriptions
+func Allowed(credential models.Credential, operation string) bool {
+	if credential.Role == models.RoleMember && credential.UserUUID == uuid.Nil {
+		return false
+	}
+	return slices.Contains(Rules[operation], credential.Role)
+}
+
+// Checking if the client of the context is allowed to perform the operation
+func authorize(ctx context.Context, operation string) error {
+	credential, ok := models.CredentialFromContext(ctx)
+	if !ok || !Allowed(credential, operation) {
+		return models.NewErrForbidden()
+	}
+	return nil
+}
+
+// Create creates the subscription if the client is allowed to
+func (p *Policy) Create(ctx context.Context, subscription models.Subscription) (models.IDResponse, error) {
+	if err := authorize(ctx, OpCreate); err != nil {
+		return models.IDResponse{}, err
+	}
+	return p.service.Create(ctx, subscription)
+}
+
+// Read reads the subscription if the client is allowed to
+func (p *Policy) Read(ctx context.Context, identifier models.SubscriptionIdentifier) (models.Subscription, error) {
+	if err := authorize(ctx, OpRead); err != nil {
+		return models.Subscription{}, err
+	}
+	return p.service.Read(ctx, identifier)
+}
+
+// Update updates the subscription if the client is allowed to
+func (p *Policy) Update(ctx context.Context, subscription models.Subscription) error {
+	if err := authorize(ctx, OpUpdate); err != nil {
+		return err
+	}
+	return p.service.Update(ctx, subscription)
+}
+
+// Patch partially updates the subscription if the client is allowed to
+func (p *Policy) Patch(ctx context.Context, patch models.SubscriptionPatch) error {
+	if err := authorize(ctx, OpPatch); err != nil {
+		return err
+	}
+	return p.service.Patch(ctx, patch)
+}
+
+// Delete moves the subscription to the trash if the client is allowed to
+func (p *Policy) Delete(ctx context.Context, identifier models.SubscriptionIdentifier) error {
+	if err := authorize(ctx, OpDelete); err != nil {
+		return err
+	}
+	return p.service.Delete(ctx, identifier)
+}
+
+// List gets the page of the subscriptions if the client is allowed to
+func (p *Policy) List(ctx context.Context, params models.SubscriptionsWithinPeriod) (models.ListResponse, error) {
+	if err := authorize(ctx, OpList); err != nil {
+		return models.ListResponse{}, err
+	}
+	return p.service.List(ctx, params)
+}
+
+// Summary gets the total price of the subscriptions if the client is allowed to
+func (p *Policy) Summary(ctx context.Context, params models.SubscriptionsWithinPeriod) (models.SummaryResponse, error) {
+	if err := authorize(ctx, OpSummary); err != nil {
+		return models.SummaryResponse{}, err
+	}
+	return p.service.Summary(ctx, params)
+}
+
+// Timeseries gets the time series of the subscriptions if the client is allowed to
+func (p *Policy) Timeseries(ctx context.Context, params models.SubscriptionsWithinPeriod) (models.TimeseriesResponse, error) {
+	if err := authorize(ctx, OpTimeseries); err != nil {
+		return models.TimeseriesResponse{}, err
+	}
+	return p.service.Timeseries(ctx, params)
+}
+
+// Restore moves the subscription back from the trash if the client is allowed to
+func (p *Policy) Restore(ctx context.Context, identifier models.SubscriptionIdentifier) error {
+	if err := authorize(ctx, OpRestore); err != nil {
+		return err
+	}
+	return p.service.Restore(ctx, identifier)
+}
+
+// Purge permanently deletes the tenant's subscriptions moved to the trash before the time if the client is allowed to. It is called by the
+// periodic purge and by the admins via the api
+func (p *Policy) Purge(ctx context.Context, before time.Time) (int, error) {
+	if err := authorize(ctx, OpPurge); err != nil {
+		return 0, err
+	}
+	return p.service.Purge(ctx, before)
+}
+
+// History gets the changes of the subscription if the client is allowed to
+func (p *Policy) History(ctx context.Context, id int) ([]models.SubscriptionEvent, error) {
+	if err := authorize(ctx, OpHistory); err != nil {
+		return nil, err
+	}
+	return p.service.History(ctx, id)
+}
+
+// SchedulePrice schedules the price change of the subscription if the client is allowed to
+func (p *Policy) SchedulePrice(ctx context.Context, id int, change models.PriceChange) error {
+	if err := authorize(ctx, OpSchedulePrice); err != nil {
+		return err
+	}
+	return p.service.SchedulePrice(ctx, id, change)
+}
+
+// Prices gets the scheduled prices of the subscription if the client is allowed to
+func (p *Policy) Prices(ctx context.Context, id int) ([]models.PriceChange, error) {
+	if err := authorize(ctx, OpPrices); err != nil {
+		return nil, err
+	}
+	return p.service.Prices(ctx, id)
+}
+
+// Bulk executes the bulk operations if the client is allowed to
+func (p *Policy) Bulk(ctx context.Context, request models.BulkRequest) ([]models.BulkResult, error) {
+	if err := authorize(ctx, OpBulk); err != nil {
+		return nil, err
+	}
+	return p.service.Bulk(ctx, request)
+}
+
+// Export passes the filtered subscriptions to the function if the client is allowed to
+func (p *Policy) Export(ctx context.Context, params models.SubscriptionsWithinPeriod, fn func(models.Subscription) error) error {
+	if err := authorize(ctx, OpExport); err != nil {
+		return err
+	}
+	return p.service.Export(ctx, params, fn)
+}
+
+// Import imports the subscriptions or validates them in dry-run mode if the client is allowed to
+func (p *Policy) Import(ctx context.Context, rows []models.ImportRow, dryRun bool) (models.ImportResult, error) {
+	if err := authorize(ctx, OpImport); err != nil {
+		return models.ImportResult{}, err
+	}
+	return p.service.Import(ctx, rows, dryRun)
+}
+
+// CreateService creates the service of the catalog if the client is allowed to
+func (p *Policy) CreateService(ctx context.Context, service models.CatalogService) (models.IDResponse, error) {
+	if err := authorize(ctx, OpCreateService); err != nil {
+		return models.IDResponse{}, err
+	}
+	return p.service.CreateService(ctx, service)
+}
+
+// ReadService reads the service of the catalog if the client is allowed to
+func (p *Policy) ReadService(ctx context.Context, id int) (models.CatalogService, error) {
+	if err := authorize(ctx, OpReadService); err != nil {
+		return models.CatalogService{}, err
+	}
+	return p.service.ReadService(ctx, id)
+}
+
+// UpdateService updates the service of the catalog if the client is allowed to
+func (p *Policy) UpdateService(ctx context.Context, service models.CatalogService) error {
+	if err := authorize(ctx, OpUpdateService); err != nil {
+		return err
+	}
+	return p.service.UpdateService(ctx, service)
+}
+
+// DeleteService deletes the service of the catalog if the client is allowed to
+func (p *Policy) DeleteService(ctx context.Context, id int) error {
+	if err := authorize(ctx, OpDeleteService); err != nil {
+		return err
+	}
+	return p.service.DeleteService(ctx, id)
+}
+
+// ListServices gets the services of the catalog if the client is allowed to
+func (p *Policy) ListServices(ctx context.Context, category string) ([]models.CatalogService, error) {
+	if err := authorize(ctx, OpListServices); err != nil {
+		return nil, err
+	}
+	return p.service.ListServices(ctx, category)
+}
+
+// IssueAPIKey issues the api key if the client is allowed to
+func (p *Policy) IssueAPIKey(ctx context.Context, key models.APIKey) (models.APIKey, error) {
+	if err := authorize(ctx, OpIssueAPIKey); err != nil {
+		return models.APIKey{}, err
+	}
+	return p.service.IssueAPIKey(ctx, key)
+}
+
+// ListAPIKeys gets the api keys if the client is allowed to
+func (p *Policy) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	if err := authorize(ctx, OpListAPIKeys); err != nil {
+		return nil, err
+	}
+	return p.service.ListAPIKeys(ctx)
+}
+
+// RevokeAPIKey revokes the api key if the client is allowed to
+func (p *Policy) RevokeAPIKey(ctx context.Context, id int) error {
+	if err := authorize(ctx, OpRevokeAPIKey); err != nil {
+		return err
+	}
+	return p.service.RevokeAPIKey(ctx, id)
+}
+
+// Rates gets the exchange rates if the client is allowed to
+func (p *Policy) Rates(ctx context.Context) (models.ExchangeRates, error) {
+	if err := authorize(ctx, OpRates); err != nil {
+		return models.ExchangeRates{}, err
+	}
+	return p.service.Rates(ctx)
+}
+
+// SetRates replaces the exchange rates if the client is allowed to
+func (p *Policy) SetRates(ctx context.Context, rates models.ExchangeRates) error {
+	if err := authorize(ctx, OpSetRates); err != nil {
+		return err
+	}
+	return p.service.SetRates(ctx, rates)
+}
@@ -0,0 +1,70 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/middelmatigheid/subscriptions-api/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// All the operations the rules are declared for
+var operations = []string{OpCreate, OpRead, OpUpdate, OpPatch, OpDelete, OpList, OpSummary, OpTimeseries, OpRestore, OpPurge, OpHistory,
+	OpSchedulePrice, OpPrices, OpBulk, OpExport, OpImport, OpCreateService, OpReadService, OpUpdateService, OpDeleteService, OpListServices,
+	OpIssueAPIKey, OpListAPIKeys, OpRevokeAPIKey, OpRates, OpSetRates}
+
+func TestRulesCoverOperations(t *testing.T) {
+	if len(Rules) != len(operations) {
+		t.Errorf("Rules declare %d operations, want %d", len(Rules), len(operations))
+	}
+	for _, operation := range operations {
+		if _, ok := Rules[operation]; !ok {
+			t.Errorf("Rules don't declare %s", operation)
+		}
+	}
+}
+
+func TestAllowed(t *testing.T) {
+	reading := []string{OpRead, OpList, OpSummary, OpTimeseries, OpHistory, OpPrices, OpExport, OpReadService, OpListServices, OpRates}
+	changing := append(slices.Clone(reading), OpCreate, OpUpdate, OpPatch, OpDelete, OpRestore, OpSchedulePrice)
+
+	tests := []struct {
+		name       string
+		credential models.Credential
+		allowed    []string
+	}{
+		{"admin", models.Credential{Role: models.RoleAdmin}, operations},
+		{"member", models.Credential{Role: models.RoleMember, UserUUID: uuid.New()}, changing},
+		{"member without user", models.Credential{Role: models.RoleMember}, nil},
+		{"editor", models.Credential{Role: models.RoleEditor}, changing},
+		{"auditor", models.Credential{Role: models.RoleAuditor}, reading},
+		{"unknown role", models.Credential{Role: "owner"}, nil},
+	}
+	for _, tt := range tests {
+		for _, operation := range operations {
+			t.Run(tt.name+" "+operation, func(t *testing.T) {
+				want := slices.Contains(tt.allowed, operation)
+				if got := Allowed(tt.credential, operation); got != want {
+					t.Errorf("Allowed() = %v, want %v", got, want)
+				}
+				err := authorize(models.WithCredential(context.Background(), tt.credential), operation)
+				if want && err != nil {
+					t.Errorf("authorize() error = %v, want nil", err)
+				} else if !want && !errors.Is(err, models.ErrForbidden) {
+					t.Errorf("authorize() error = %v, want forbidden", err)
+				}
+			})
+		}
+	}
+}
+
+func TestAuthorizeWithoutCredential(t *testing.T) {
+	for _, operation := range operations {
+		if err := authorize(context.Background(), operation); !errors.Is(err, models.ErrForbidden) {
+			t.Errorf("authorize(%s) error = %v, want forbidden", operation, err)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Buckets are being checked for removal at most once per interval
+const sweepInterval = time.Minute
+
+// Memory is an in-process limiter, the buckets aren't shared between several instances of the api
+type Memory struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	fullAt    time.Time
+}
+
+// Creates in-process limiter
+func NewMemory() *Memory {
+	return &Memory{buckets: make(map[string]*bucket), swept: time.Now()}
+}
+
+// Taking the token from the bucket of the key, the new bucket is full
+func (m *Memory) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.sweep(now)
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updatedAt: now}
+		m.buckets[key] = b
+	}
+	var res Result
+	b.tokens, res = take(b.tokens, now.Sub(b.updatedAt), limit)
+	b.updatedAt, b.fullAt = now, now.Add(res.Reset)
+	return res, nil
+}
+
+// Dropping the buckets which are full, they are the same as the new ones. The lock should be held by the caller
+func (m *Memory) sweep(now time.Time) {
+	if now.Sub(m.swept) < sweepInterval {
+		return
+	}
+	m.swept = now
+	for key, b := range m.buckets {
+		if !b.fullAt.After(now) {
+			delete(m.buckets, key)
+		}
+	}
+}
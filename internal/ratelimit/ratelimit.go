@@ -0,0 +1,142 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/middelmatigheid/subscriptions-api/internal/auth"
+	"github.com/middelmatigheid/subscriptions-api/internal/config"
+	"github.com/middelmatigheid/subscriptions-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// Limit of the requests made by the client. The bucket holds up to burst tokens and is being refilled with rate tokens per minute, each request takes
+// one token
+type Limit struct {
+	Rate  int
+	Burst int
+}
+
+// Tokens added to the bucket per second
+func (l Limit) perSecond() float64 {
+	return float64(l.Rate) / 60
+}
+
+// Result of taking the token. The client waits for retry after until the next token if the request is not allowed, the bucket is full again after
+// reset
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+	Reset      time.Duration
+}
+
+// Limiter keeps the token buckets of the clients. The bucket is specified by the key
+type Limiter interface {
+	Allow(context.Context, string, Limit) (Result, error)
+}
+
+// Creates limiter of the configured kind, the redis limiter uses the client shared with the cache. Nil limiter is returned if rate limiting is
+// disabled
+func NewLimiter(config *config.Config, client *redis.Client) (Limiter, error) {
+	switch config.RateLimit {
+	case "redis":
+		if client == nil {
+			return nil, models.NewErrInternalServer(errors.New("Redis client is not connected"))
+		}
+		return NewRedis(client), nil
+	case "memory":
+		return NewMemory(), nil
+	case "none":
+		return nil, nil
+	default:
+		return nil, models.NewErrInternalServer(errors.New("Unknown rate limit " + config.RateLimit))
+	}
+}
+
+// Refilling the bucket for the elapsed time and taking one token if there is any. The tokens left in the bucket are returned with the result
+func take(tokens float64, elapsed time.Duration, limit Limit) (float64, Result) {
+	tokens = math.Min(float64(limit.Burst), tokens+math.Max(0, elapsed.Seconds())*limit.perSecond())
+	allowed := tokens >= 1
+	if allowed {
+		tokens--
+	}
+	return tokens, result(allowed, tokens, limit)
+}
+
+// Getting the result by the tokens left in the bucket
+func result(allowed bool, tokens float64, limit Limit) Result {
+	res := Result{Allowed: allowed, Limit: limit.Burst, Remaining: int(math.Floor(tokens))}
+	if rate := limit.perSecond(); rate > 0 {
+		res.Reset = time.Duration((float64(limit.Burst) - tokens) / rate * float64(time.Second))
+		if !allowed {
+			res.RetryAfter = time.Duration((1 - tokens) / rate * float64(time.Second))
+		}
+	}
+	return res
+}
+
+// Getting the key of the client's bucket. The clients are told apart by the api key or the token, the clients without them are told apart by ip
+func clientKey(c *gin.Context, name string) string {
+	if key := auth.RequestKey(c); key != "" {
+		return name + ":key:" + auth.HashKey(key)
+	}
+	return ipKey(c, name)
+}
+
+// Getting the key of the bucket of the ip. The ip is taken from the forwarding headers only if the request came through the trusted proxy
+func ipKey(c *gin.Context, name string) string {
+	return name + ":ip:" + c.ClientIP()
+}
+
+// Rounding the duration up to whole seconds
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// Middleware limits the requests of each client to the limit, the requests passing the middlewares of the same name share the client's bucket.
+// The state of the bucket is returned in RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers, the requests over the limit are
+// rejected with 429 and Retry-After header. The requests are let through if the limiter fails, so the api stays available
+func Middleware(limiter Limiter, name string, limit Limit) gin.HandlerFunc {
+	return middleware(limiter, name, limit, clientKey)
+}
+
+// IPMiddleware limits the requests from each ip to the limit whatever the credential is. It is used before the authentication, so the clients
+// can't bypass it by sending made up keys
+func IPMiddleware(limiter Limiter, name string, limit Limit) gin.HandlerFunc {
+	return middleware(limiter, name, limit, ipKey)
+}
+
+// Limiting the requests sharing the bucket of the key
+func middleware(limiter Limiter, name string, limit Limit, key func(*gin.Context, string) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limiter == nil || limit.Rate <= 0 || limit.Burst <= 0 {
+			c.Next()
+			return
+		}
+
+		res, err := limiter.Allow(c.Request.Context(), key(c, name), limit)
+		if err != nil {
+			c.Error(err)
+			c.Next()
+			return
+		}
+		c.Header("RateLimit-Limit", strconv.Itoa(res.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		c.Header("RateLimit-Reset", seconds(res.Reset))
+		if !res.Allowed {
+			c.Header("Retry-After", seconds(res.RetryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"msg": "Too many requests, retry after " + seconds(res.RetryAfter) + " seconds",
+				"error": http.StatusText(http.StatusTooManyRequests)})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Getting the router limited by the middleware, the router trusts no proxies
+func newRouter(t *testing.T, middleware gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	server := gin.New()
+	if err := server.SetTrustedProxies(nil); err != nil {
+		t.Fatalf("SetTrustedProxies() error = %v", err)
+	}
+	server.GET("/", middleware, func(c *gin.Context) { c.Status(http.StatusOK) })
+	return server
+}
+
+func TestIPMiddleware(t *testing.T) {
+	server := newRouter(t, IPMiddleware(NewMemory(), "ip", Limit{Rate: 1, Burst: 2}))
+
+	// The keys and the forwarding headers don't give the ip a new bucket
+	tests := []struct {
+		name       string
+		remote     string
+		key        string
+		forwarded  string
+		wantStatus int
+	}{
+		{"first request", "10.0.0.1:1000", "first", "", http.StatusOK},
+		{"other key", "10.0.0.1:1000", "second", "", http.StatusOK},
+		{"spoofed ip", "10.0.0.1:1000", "third", "192.168.0.7", http.StatusTooManyRequests},
+		{"other ip", "10.0.0.2:1000", "first", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remote
+			req.Header.Set("X-API-Key", tt.key)
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			w := httptest.NewRecorder()
+			server.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Errorf("GET / = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestMiddlewareKeys(t *testing.T) {
+	server := newRouter(t, Middleware(NewMemory(), "all", Limit{Rate: 1, Burst: 1}))
+	for _, key := range []string{"first", "second"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-API-Key", key)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Errorf("GET / with %s key = %d, want %d", key, w.Code, http.StatusOK)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Prefix of the keys of the buckets
+const redisPrefix = "ratelimit:"
+
+// Refilling the bucket and taking the token atomically. The time of redis is used, so the instances of the api with different clocks share the
+// bucket. The bucket expires when it is full again. The script returns whether the token was taken and the tokens left
+var takeScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) + tonumber(time[2]) / 1000000
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'updated_at')
+local tokens = tonumber(bucket[1]) or burst
+local updated = tonumber(bucket[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - updated) * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated_at', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) / rate * 1000) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+// Redis is a limiter stored in redis, the buckets are shared between several instances of the api
+type Redis struct {
+	client *redis.Client
+}
+
+// Creates redis limiter using the client shared with the cache
+func NewRedis(client *redis.Client) *Redis {
+	return &Redis{client: client}
+}
+
+// Taking the token from the bucket of the key, the new bucket is full
+func (l *Redis) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	values, err := takeScript.Run(ctx, l.client, []string{redisPrefix + key}, limit.perSecond(), limit.Burst).Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	allowed, _ := values[0].(int64)
+	left, _ := values[1].(string)
+	tokens, err := strconv.ParseFloat(left, 64)
+	if err != nil {
+		return Result{}, err
+	}
+	return result(allowed == 1, tokens, limit), nil
+}
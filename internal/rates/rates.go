@@ -0,0 +1,186 @@
+package rates
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/middelmatigheid/subscriptions-api/internal/config"
+	"github.com/middelmatigheid/subscriptions-api/internal/models"
+)
+
+// ISO 4217 currency code
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Rates is a local table of exchange rates used to convert the prices between currencies. The base currency is fixed by the config since the prices
+// without currency are in it. The loaded table is shared by the tenants until they replace it with their own
+type Rates struct {
+	mu      sync.RWMutex
+	base    string
+	rates   models.ExchangeRates
+	tenants map[string]models.ExchangeRates
+}
+
+// Creates exchange rates table. The table is being loaded from the file or the url if one of them is configured, the loaded rates with the base
+// currency other than the configured one are rejected
+func NewRates(config *config.Config) (*Rates, error) {
+	if err := ValidateCurrency(config.RatesBase); err != nil {
+		return nil, models.NewErrInternalServer(err)
+	}
+	r := &Rates{base: config.RatesBase, rates: models.ExchangeRates{Base: config.RatesBase, Rates: map[string]float64{config.RatesBase: 1}},
+		tenants: make(map[string]models.ExchangeRates)}
+
+	// Loading the rates from the file
+	if config.RatesFile != "" {
+		file, err := os.Open(config.RatesFile)
+		if err != nil {
+			return nil, models.NewErrInternalServer(err)
+		}
+		defer file.Close()
+		if err = r.Load(file); err != nil {
+			return nil, err
+		}
+	}
+
+	// Loading the rates from the url
+	if config.RatesURL != "" {
+		client := http.Client{Timeout: 10 * time.Second}
+		res, err := client.Get(config.RatesURL)
+		if err != nil {
+			return nil, models.NewErrInternalServer(err)
+		}
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			return nil, models.NewErrInternalServer(fmt.Errorf("Exchange rates url responded with %d", res.StatusCode))
+		}
+		if err = r.Load(res.Body); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// ValidateCurrency checks if the currency is ISO 4217 code
+func ValidateCurrency(currency string) error {
+	if !currencyCode.MatchString(currency) {
+		return models.NewErrBadRequest(errors.New("Invalid currency " + currency))
+	}
+	return nil
+}
+
+// Load replaces the shared rates with ones read from json
+func (r *Rates) Load(reader io.Reader) error {
+	var rates models.ExchangeRates
+	if err := json.NewDecoder(reader).Decode(&rates); err != nil {
+		return models.NewErrBadRequest(err)
+	}
+	table, err := r.validate(rates)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rates = table
+	return nil
+}
+
+// Get returns copy of the current rates of the tenant
+func (r *Rates) Get(ctx context.Context) models.ExchangeRates {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	current := r.current(ctx)
+	rates := models.ExchangeRates{Base: current.Base, Rates: make(map[string]float64, len(current.Rates))}
+	for currency, rate := range current.Rates {
+		rates.Rates[currency] = rate
+	}
+	return rates
+}
+
+// Set validates and replaces the current rates of the tenant, the shared rates are kept for the other tenants. The configured base currency is
+// used if the rates don't provide one, the rates with other base currency are rejected
+func (r *Rates) Set(ctx context.Context, rates models.ExchangeRates) error {
+	table, err := r.validate(rates)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tenants[models.TenantFromContext(ctx)] = table
+	return nil
+}
+
+// Getting the rates of the tenant, the shared rates are used if the tenant hasn't replaced them. The lock should be held by the caller
+func (r *Rates) current(ctx context.Context) models.ExchangeRates {
+	if rates, ok := r.tenants[models.TenantFromContext(ctx)]; ok {
+		return rates
+	}
+	return r.rates
+}
+
+// Validating the rates and building the table including the base currency
+func (r *Rates) validate(rates models.ExchangeRates) (models.ExchangeRates, error) {
+	if rates.Base == "" {
+		rates.Base = r.base
+	}
+	if err := ValidateCurrency(rates.Base); err != nil {
+		return models.ExchangeRates{}, err
+	}
+	if rates.Base != r.base {
+		return models.ExchangeRates{}, models.NewErrBadRequest(fmt.Errorf("Base currency %s doesn't match the configured base currency %s", rates.Base, r.base))
+	}
+	table := map[string]float64{rates.Base: 1}
+	for currency, rate := range rates.Rates {
+		if err := ValidateCurrency(currency); err != nil {
+			return models.ExchangeRates{}, err
+		}
+		if rate <= 0 {
+			return models.ExchangeRates{}, models.NewErrBadRequest(errors.New("Invalid rate of " + currency))
+		}
+		if currency != rates.Base {
+			table[currency] = rate
+		}
+	}
+	return models.ExchangeRates{Base: rates.Base, Rates: table}, nil
+}
+
+// Base returns the base currency of the rates, it is the same for all the tenants
+func (r *Rates) Base() string {
+	return r.base
+}
+
+// Rate returns the price of one unit of the currency from in the currency to by the rates of the tenant
+func (r *Rates) Rate(ctx context.Context, from, to string) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rates := r.current(ctx)
+	fromRate, ok := rates.Rates[from]
+	if !ok && from == rates.Base {
+		fromRate, ok = 1, true
+	}
+	if !ok {
+		return 0, models.NewErrBadRequest(errors.New("Unknown exchange rate of " + from))
+	}
+	toRate, ok := rates.Rates[to]
+	if !ok && to == rates.Base {
+		toRate, ok = 1, true
+	}
+	if !ok {
+		return 0, models.NewErrBadRequest(errors.New("Unknown exchange rate of " + to))
+	}
+	return fromRate / toRate, nil
+}
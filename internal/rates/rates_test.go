@@ -0,0 +1,88 @@
+package rates
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/middelmatigheid/subscriptions-api/internal/config"
+	"github.com/middelmatigheid/subscriptions-api/internal/models"
+)
+
+func TestNewRatesBase(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		wantErr bool
+	}{
+		{"matching base", `{"base": "RUB", "rates": {"USD": 81.5}}`, false},
+		{"omitted base", `{"rates": {"USD": 81.5}}`, false},
+		{"other base", `{"base": "USD", "rates": {"RUB": 0.012}}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "rates.json")
+			os.WriteFile(file, []byte(tt.file), 0644)
+			r, err := NewRates(&config.Config{RatesBase: "RUB", RatesFile: file})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewRates() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && r.Base() != "RUB" {
+				t.Errorf("Base() = %s, want RUB", r.Base())
+			}
+		})
+	}
+}
+
+func TestSetBase(t *testing.T) {
+	r, err := NewRates(&config.Config{RatesBase: "RUB"})
+	if err != nil {
+		t.Fatalf("NewRates() error = %v", err)
+	}
+	ctx := context.Background()
+	if err = r.Set(ctx, models.ExchangeRates{Base: "USD", Rates: map[string]float64{"RUB": 0.012}}); !errors.Is(err, models.ErrBadRequest) {
+		t.Errorf("Set() other base error = %v, want bad request", err)
+	}
+	if err = r.Set(ctx, models.ExchangeRates{Rates: map[string]float64{"USD": 80}}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if rate, err := r.Rate(ctx, "USD", "RUB"); err != nil || rate != 80 {
+		t.Errorf("Rate(USD, RUB) = %v, %v, want 80", rate, err)
+	}
+}
+
+func TestSetTenants(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "rates.json")
+	os.WriteFile(file, []byte(`{"base": "RUB", "rates": {"USD": 81.5}}`), 0644)
+	r, err := NewRates(&config.Config{RatesBase: "RUB", RatesFile: file})
+	if err != nil {
+		t.Fatalf("NewRates() error = %v", err)
+	}
+	finance := models.WithCredential(context.Background(), models.Credential{TenantID: "finance"})
+	sales := models.WithCredential(context.Background(), models.Credential{TenantID: "sales"})
+	if err = r.Set(finance, models.ExchangeRates{Rates: map[string]float64{"USD": 90}}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want float64
+	}{
+		{"tenant replaced the rates", finance, 90},
+		{"other tenant keeps the loaded rates", sales, 81.5},
+		{"no tenant keeps the loaded rates", context.Background(), 81.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rate, err := r.Rate(tt.ctx, "USD", "RUB"); err != nil || rate != tt.want {
+				t.Errorf("Rate(USD, RUB) = %v, %v, want %v", rate, err, tt.want)
+			}
+			if got := r.Get(tt.ctx).Rates["USD"]; got != tt.want {
+				t.Errorf("Get() USD = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/middelmatigheid/subscriptions-api/internal/models"
+	"github.com/middelmatigheid/subscriptions-api/internal/rates"
+)
+
+// Validating service of the catalog
+func validateService(service models.CatalogService) error {
+	if service.Name == "" {
+		return models.NewErrBadRequest(errors.New("Empty service name"))
+	}
+	if service.DefaultPrice < 0 {
+		return models.NewErrBadRequest(errors.New("Invalid default price"))
+	}
+	return rates.ValidateCurrency(service.Currency)
+}
+
+// Trimming the names of the service, empty and duplicated aliases are being dropped. The default price is implied to be in the base currency
+// if the currency isn't provided
+func (s *Service) normalizeService(service *models.CatalogService) {
+	service.Name = strings.Join(strings.Fields(service.Name), " ")
+	aliases := []string{}
+	for _, alias := range service.Aliases {
+		alias = strings.Join(strings.Fields(alias), " ")
+		if alias != "" && !slices.Contains(aliases, alias) {
+			aliases = append(aliases, alias)
+		}
+	}
+	service.Aliases = aliases
+	service.Currency = strings.ToUpper(service.Currency)
+	if service.Currency == "" {
+		service.Currency = s.ExchangeRates.Base()
+	}
+}
+
+// Creating new service of the catalog. The subscriptions named by any of the names of the service are being linked to it
+func (s *Service) CreateService(ctx context.Context, service models.CatalogService) (models.IDResponse, error) {
+	s.normalizeService(&service)
+	if err := validateService(service); err != nil {
+		return models.IDResponse{}, err
+	}
+	res, linked, err := s.Database.CreateService(ctx, service)
+	if s.Cache != nil {
+		for _, id := range linked {
+			s.Cache.DeleteSubscription(ctx, models.SubscriptionIdentifier{ID: id})
+		}
+	}
+	return res, err
+}
+
+// Reading service of the catalog
+func (s *Service) ReadService(ctx context.Context, id int) (models.CatalogService, error) {
+	if id <= 0 {
+		return models.CatalogService{}, models.NewErrBadRequest(errors.New("Invalid id"))
+	}
+	return s.Database.ReadService(ctx, id)
+}
+
+// Updating service of the catalog. The subscriptions referencing the service are being renamed, the previous name is kept as an alias so it is
+// still resolved
+func (s *Service) UpdateService(ctx context.Context, service models.CatalogService) error {
+	s.normalizeService(&service)
+	if err := validateService(service); err != nil {
+		return err
+	}
+	exists, err := s.ReadService(ctx, service.ID)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(service.Names(), models.NormalizeServiceName(exists.Name)) {
+		service.Aliases = append(service.Aliases, exists.Name)
+	}
+	renamed, err := s.Database.UpdateService(ctx, service)
+	if s.Cache != nil {
+		for _, id := range renamed {
+			s.Cache.DeleteSubscription(ctx, models.SubscriptionIdentifier{ID: id})
+		}
+	}
+	return err
+}
+
+// Deleting service of the catalog
+func (s *Service) DeleteService(ctx context.Context, id int) error {
+	if id <= 0 {
+		return models.NewErrBadRequest(errors.New("Invalid id"))
+	}
+	return s.Database.DeleteService(ctx, id)
+}
+
+// Getting services of the catalog
+func (s *Service) ListServices(ctx context.Context, category string) ([]models.CatalogService, error) {
+	return s.Database.ListServices(ctx, category)
+}
+
+// Replacing service name of the subscription by the canonical name of the catalog's service, the service's default price in its currency and the
+// category are used if the subscription doesn't provide them. The price provided without the currency stays in the base currency. Service names
+// which aren't in the catalog are kept as they are
+func (s *Service) resolveService(ctx context.Context, subscription *models.Subscription) error {
+	subscription.ServiceID = 0
+	if subscription.ServiceName == "" {
+		return nil
+	}
+	service, err := s.Database.ResolveService(ctx, subscription.ServiceName)
+	if errors.Is(err, models.ErrNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	subscription.ServiceName, subscription.ServiceID = service.Name, service.ID
+	if subscription.Price == 0 && service.DefaultPrice > 0 {
+		subscription.Price, subscription.Currency = service.DefaultPrice, service.Currency
+	}
+	if subscription.Category == "" {
+		subscription.Category = service.Category
+	}
+	return nil
+}
+
+// Getting canonical name of the service, names which aren't in the catalog are returned as they are
+func (s *Service) canonicalName(ctx context.Context, name string) (string, error) {
+	if name == "" {
+		return name, nil
+	}
+	service, err := s.Database.ResolveService(ctx, name)
+	if errors.Is(err, models.ErrNotFound) {
+		return name, nil
+	} else if err != nil {
+		return "", err
+	}
+	return service.Name, nil
+}
+
+// Replacing the service name the subscriptions are filtered by with the canonical name, if the name is matched exactly
+func (s *Service) resolveFilters(ctx context.Context, params *models.SubscriptionsWithinPeriod) error {
+	if params.ServiceNameMatch != "" && params.ServiceNameMatch != models.MatchExact {
+		return nil
+	}
+	var err error
+	params.ServiceName, err = s.canonicalName(ctx, params.ServiceName)
+	return err
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/middelmatigheid/subscriptions-api/internal/auth"
+	"github.com/middelmatigheid/subscriptions-api/internal/models"
+)
+
+// Validating api key being issued, duplicated scopes are being dropped
+func validateAPIKey(key *models.APIKey) error {
+	key.Name = strings.TrimSpace(key.Name)
+	if key.Name == "" {
+		return models.NewErrBadRequest(errors.New("Empty api key name"))
+	}
+	scopes := []string{}
+	for _, scope := range key.Scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		switch scope {
+		case models.ScopeRead, models.ScopeWrite, models.ScopeAdmin:
+		default:
+			return models.NewErrBadRequest(errors.New("Invalid scope " + scope))
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) == 0 {
+		return models.NewErrBadRequest(errors.New("Empty scopes"))
+	}
+	key.Scopes = scopes
+	if key.ExpiresAt.Valid && !key.ExpiresAt.Time.After(time.Now()) {
+		return models.NewErrBadRequest(errors.New("Invalid expiration time"))
+	}
+	return nil
+}
+
+// Issuing new api key within the tenant of the client. The key is returned only once, only its hash is being stored
+func (s *Service) IssueAPIKey(ctx context.Context, key models.APIKey) (models.APIKey, error) {
+	if err := validateAPIKey(&key); err != nil {
+		return models.APIKey{}, err
+	}
+	key.TenantID = models.TenantFromContext(ctx)
+	var err error
+	key.Key, key.Prefix, err = auth.GenerateKey()
+	if err != nil {
+		return models.APIKey{}, err
+	}
+	key.Hash = auth.HashKey(key.Key)
+	res, err := s.Database.CreateAPIKey(ctx, key)
+	if err != nil {
+		return models.APIKey{}, err
+	}
+	key.ID, key.Hash = res.ID, ""
+	key.CreatedAt = models.CustomTime{}
+	key.CreatedAt.Time, key.CreatedAt.Valid = time.Now(), true
+	return key, nil
+}
+
+// Getting the issued api keys
+func (s *Service) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	return s.Database.ListAPIKeys(ctx)
+}
+
+// Revoking api key, the revoked key is rejected since then
+func (s *Service) RevokeAPIKey(ctx context.Context, id int) error {
+	if id <= 0 {
+		return models.NewErrBadRequest(errors.New("Invalid id"))
+	}
+	return s.Database.RevokeAPIKey(ctx, id)
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+
+	"github.com/middelmatigheid/subscriptions-api/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Getting the user the request is restricted to. The clients acting on behalf of the user reach only the user's subscriptions
+func restrictedUser(ctx context.Context) (uuid.UUID, bool) {
+	credential, ok := models.CredentialFromContext(ctx)
+	return credential.UserUUID, ok && credential.UserUUID != uuid.Nil
+}
+
+// Assigning the subscription to the user the request is restricted to, the subscription of another user is forbidden
+func ownSubscription(ctx context.Context, subscription *models.Subscription) error {
+	user, ok := restrictedUser(ctx)
+	switch {
+	case !ok:
+	case subscription.UserUUID == uuid.Nil:
+		subscription.UserUUID = user
+	case subscription.UserUUID != user:
+		return models.NewErrForbidden()
+	}
+	return nil
+}
+
+// Restricting the identifier to the user the request is restricted to, subscriptions of other users are not found
+func restrictIdentifier(ctx context.Context, identifier *models.SubscriptionIdentifier) error {
+	user, ok := restrictedUser(ctx)
+	if !ok {
+		return nil
+	}
+	if identifier.UserUUID != uuid.Nil && identifier.UserUUID != user {
+		return models.NewErrNotFound()
+	}
+	identifier.UserUUID = user
+	return nil
+}
+
+// Restricting the filters to the user the request is restricted to, filtering by other users is forbidden
+func restrictFilters(ctx context.Context, params *models.SubscriptionsWithinPeriod) error {
+	user, ok := restrictedUser(ctx)
+	if !ok {
+		return nil
+	}
+	for _, userUUID := range params.UserUUIDs {
+		if userUUID != user {
+			return models.NewErrForbidden()
+		}
+	}
+	params.UserUUIDs = []uuid.UUID{user}
+	return nil
+}
+
+// Checking if the stored subscription belongs to the user the request is restricted to, subscriptions of other users are not found
+func (s *Service) checkOwner(ctx context.Context, id int) error {
+	user, ok := restrictedUser(ctx)
+	if !ok {
+		return nil
+	}
+	_, err := s.Database.Read(ctx, models.SubscriptionIdentifier{ID: id, UserUUID: user})
+	return err
+}
@@ -3,25 +3,32 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/middelmatigheid/subscriptions-api/internal/cache"
 	"github.com/middelmatigheid/subscriptions-api/internal/config"
 	"github.com/middelmatigheid/subscriptions-api/internal/models"
+	"github.com/middelmatigheid/subscriptions-api/internal/rates"
 
 	"github.com/google/uuid"
 )
 
 type Service struct {
-	Database models.Storage
-	Cache    *cache.Cache
+	Database      models.Storage
+	Cache         cache.Cache
+	ExchangeRates *rates.Rates
 }
 
-func NewService(config *config.Config, db models.Storage) (*Service, error) {
-	cache, err := cache.NewCache(config)
+func NewService(config *config.Config, db models.Storage, cache cache.Cache) (*Service, error) {
+	rates, err := rates.NewRates(config)
 	if err != nil {
-		return nil, nil
+		return nil, err
 	}
-	return &Service{Database: db, Cache: cache}, nil
+	return &Service{Database: db, Cache: cache, ExchangeRates: rates}, nil
 }
 
 // Validating subscription
@@ -41,6 +48,21 @@ func (s *Service) ValidateSubscription(subscription models.Subscription) error {
 		return models.NewErrBadRequest(errors.New("Invalid price"))
 	}
 
+	// Validating currency
+	if err := rates.ValidateCurrency(subscription.Currency); err != nil {
+		return err
+	}
+
+	// Validating billing period
+	switch subscription.BillingUnit {
+	case models.BillingWeek, models.BillingMonth, models.BillingQuarter, models.BillingYear:
+	default:
+		return models.NewErrBadRequest(errors.New("Invalid billing unit"))
+	}
+	if subscription.BillingInterval <= 0 {
+		return models.NewErrBadRequest(errors.New("Invalid billing interval"))
+	}
+
 	// Validating time bounds
 	if !subscription.StartDate.Valid || (subscription.EndDate.Valid && subscription.EndDate.Time.Before(subscription.StartDate.Time)) {
 		return models.NewErrBadRequest(errors.New("Invalid time bounds"))
@@ -48,8 +70,58 @@ func (s *Service) ValidateSubscription(subscription models.Subscription) error {
 	return nil
 }
 
+// Validating filters of the list
+func validateFilters(params models.SubscriptionsWithinPeriod) error {
+	// Validating time bounds
+	if params.EndDate.Valid && params.StartDate.Valid && params.EndDate.Time.Before(params.StartDate.Time) {
+		return models.NewErrBadRequest(errors.New("Invalid time bound"))
+	}
+	// Validating price range
+	if params.PriceMin < 0 || params.PriceMax < 0 || params.PriceMax > 0 && params.PriceMin > params.PriceMax {
+		return models.NewErrBadRequest(errors.New("Invalid price range"))
+	}
+	return nil
+}
+
+// Monthly billing is implied if the billing period isn't provided, the price is implied to be in the base currency if the currency isn't provided.
+// Tags are being trimmed, lowered and deduplicated
+func setDefaults(subscription *models.Subscription, base string) {
+	subscription.Category = strings.TrimSpace(subscription.Category)
+	subscription.Tags = normalizeTags(subscription.Tags)
+	subscription.Currency = strings.ToUpper(subscription.Currency)
+	if subscription.Currency == "" {
+		subscription.Currency = base
+	}
+	if subscription.BillingUnit == "" {
+		subscription.BillingUnit = models.BillingMonth
+	}
+	if subscription.BillingInterval == 0 {
+		subscription.BillingInterval = 1
+	}
+}
+
+// Getting tags trimmed, in lower case, ordered and without duplicates
+func normalizeTags(tags []string) []string {
+	normalized := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.Join(strings.Fields(tag), " "))
+		if tag != "" && !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	slices.Sort(normalized)
+	return normalized
+}
+
 // Creating new subscription
 func (s *Service) Create(ctx context.Context, subscription models.Subscription) (models.IDResponse, error) {
+	if err := ownSubscription(ctx, &subscription); err != nil {
+		return models.IDResponse{}, err
+	}
+	if err := s.resolveService(ctx, &subscription); err != nil {
+		return models.IDResponse{}, err
+	}
+	setDefaults(&subscription, s.ExchangeRates.Base())
 	err := s.ValidateSubscription(subscription)
 	if err != nil {
 		return models.IDResponse{}, err
@@ -57,32 +129,48 @@ func (s *Service) Create(ctx context.Context, subscription models.Subscription)
 
 	// Inserting the subscription into the database
 	res, err := s.Database.Create(ctx, subscription)
-	if s.Cache != nil {
-		subscription.ID = res.ID
-		s.Cache.SetSubscription(ctx, subscription)
-	}
 	return res, err
 }
 
 // Reading subscription
 func (s *Service) Read(ctx context.Context, identifier models.SubscriptionIdentifier) (models.Subscription, error) {
+	if err := restrictIdentifier(ctx, &identifier); err != nil {
+		return models.Subscription{}, err
+	}
 	if identifier.ID == 0 && (identifier.UserUUID == uuid.Nil || len(identifier.ServiceName) == 0) {
 		return models.Subscription{}, models.NewErrBadRequest(errors.New("Not enough arguments"))
 	}
+	var err error
+	if identifier.ServiceName, err = s.canonicalName(ctx, identifier.ServiceName); err != nil {
+		return models.Subscription{}, err
+	}
 
 	if s.Cache != nil {
 		sub, err := s.Cache.GetSubscription(ctx, identifier)
-		if err == nil && sub != nil {
+		if err == nil && sub != nil && (identifier.UserUUID == uuid.Nil || sub.UserUUID == identifier.UserUUID) {
 			return *sub, nil
 		}
 	}
-	// Getting subscription's info from the database
+	// Getting subscription's info from the database, the subscription is being cached in with its version
 	res, err := s.Database.Read(ctx, identifier)
+	if err == nil && s.Cache != nil {
+		s.Cache.SetSubscription(ctx, res)
+	}
 	return res, err
 }
 
 // Updating the subscription
 func (s *Service) Update(ctx context.Context, subscription models.Subscription) error {
+	if err := s.checkOwner(ctx, subscription.ID); err != nil {
+		return err
+	}
+	if err := ownSubscription(ctx, &subscription); err != nil {
+		return err
+	}
+	if err := s.resolveService(ctx, &subscription); err != nil {
+		return err
+	}
+	setDefaults(&subscription, s.ExchangeRates.Base())
 	err := s.ValidateSubscription(subscription)
 	if err != nil {
 		return err
@@ -98,14 +186,24 @@ func (s *Service) Update(ctx context.Context, subscription models.Subscription)
 
 // Updating the subscription partially
 func (s *Service) Patch(ctx context.Context, subscriptionPatch models.SubscriptionPatch) error {
-	exists, err := s.Database.Read(ctx, models.SubscriptionIdentifier{ID: subscriptionPatch.ID})
+	identifier := models.SubscriptionIdentifier{ID: subscriptionPatch.ID}
+	if err := restrictIdentifier(ctx, &identifier); err != nil {
+		return err
+	}
+	exists, err := s.Database.Read(ctx, identifier)
 	if err != nil {
 		return err
 	}
 
+	// The patch is being applied to the read version of the subscription so concurrent changes aren't overwritten
+	if subscriptionPatch.Version != 0 && subscriptionPatch.Version != exists.Version {
+		return models.NewErrPrecondition()
+	}
+
 	// Configuring updated subscription. If the field wasn't provided it remains unchanged
 	var subscription models.Subscription
 	subscription.ID = subscriptionPatch.ID
+	subscription.Version = exists.Version
 
 	// Getting user uuid
 	if subscriptionPatch.UserUUID != nil {
@@ -128,6 +226,37 @@ func (s *Service) Patch(ctx context.Context, subscriptionPatch models.Subscripti
 		subscription.Price = exists.Price
 	}
 
+	// Getting currency
+	if subscriptionPatch.Currency != nil {
+		subscription.Currency = strings.ToUpper(*subscriptionPatch.Currency)
+	} else {
+		subscription.Currency = exists.Currency
+	}
+
+	// Getting billing period
+	if subscriptionPatch.BillingUnit != nil {
+		subscription.BillingUnit = *subscriptionPatch.BillingUnit
+	} else {
+		subscription.BillingUnit = exists.BillingUnit
+	}
+	if subscriptionPatch.BillingInterval != nil {
+		subscription.BillingInterval = *subscriptionPatch.BillingInterval
+	} else {
+		subscription.BillingInterval = exists.BillingInterval
+	}
+
+	// Getting category and tags
+	if subscriptionPatch.Category != nil {
+		subscription.Category = strings.TrimSpace(*subscriptionPatch.Category)
+	} else {
+		subscription.Category = exists.Category
+	}
+	if subscriptionPatch.Tags != nil {
+		subscription.Tags = normalizeTags(*subscriptionPatch.Tags)
+	} else {
+		subscription.Tags = exists.Tags
+	}
+
 	// Getting time bounds
 	if subscriptionPatch.StartDate != nil {
 		subscription.StartDate = *subscriptionPatch.StartDate
@@ -141,6 +270,12 @@ func (s *Service) Patch(ctx context.Context, subscriptionPatch models.Subscripti
 	}
 
 	// Validating subscription
+	if err = ownSubscription(ctx, &subscription); err != nil {
+		return err
+	}
+	if err = s.resolveService(ctx, &subscription); err != nil {
+		return err
+	}
 	err = s.ValidateSubscription(subscription)
 	if err != nil {
 		return err
@@ -156,11 +291,28 @@ func (s *Service) Patch(ctx context.Context, subscriptionPatch models.Subscripti
 
 // Deleting the subscription
 func (s *Service) Delete(ctx context.Context, identifier models.SubscriptionIdentifier) error {
+	if err := restrictIdentifier(ctx, &identifier); err != nil {
+		return err
+	}
+
 	// Id or combination of user uuid and service name should be provided to specify the subscription
 	if identifier.ID == 0 && (identifier.UserUUID == uuid.Nil || len(identifier.ServiceName) == 0) {
 		return models.NewErrBadRequest(errors.New("Not enough arguments"))
 	}
 
+	// Getting id of the subscription so it can be removed from the cache
+	if identifier.ID == 0 {
+		var err error
+		if identifier.ServiceName, err = s.canonicalName(ctx, identifier.ServiceName); err != nil {
+			return err
+		}
+		exists, err := s.Database.Read(ctx, identifier)
+		if err != nil {
+			return err
+		}
+		identifier.ID = exists.ID
+	}
+
 	// Deleting the subscription from the database
 	err := s.Database.Delete(ctx, identifier)
 	if s.Cache != nil {
@@ -169,24 +321,455 @@ func (s *Service) Delete(ctx context.Context, identifier models.SubscriptionIden
 	return err
 }
 
-// Gettng list of subscrtiption
-func (s *Service) List(ctx context.Context, params models.SubscriptionsWithinPeriod) ([]models.Subscription, error) {
-	// Validating time bounds
-	if params.EndDate.Valid && params.StartDate.Valid && params.EndDate.Time.Before(params.StartDate.Time) {
-		return []models.Subscription{}, models.NewErrBadRequest(errors.New("Invalid time bound"))
+// Restoring the deleted subscription
+func (s *Service) Restore(ctx context.Context, identifier models.SubscriptionIdentifier) error {
+	// Only id specifies the deleted subscription
+	if identifier.ID <= 0 {
+		return models.NewErrBadRequest(errors.New("Invalid id"))
+	}
+	if err := restrictIdentifier(ctx, &identifier); err != nil {
+		return err
+	}
+	return s.Database.Restore(ctx, identifier)
+}
+
+// Purging the tenant's subscriptions which were moved to the trash before the time
+func (s *Service) Purge(ctx context.Context, before time.Time) (int, error) {
+	return s.Database.Purge(ctx, before)
+}
+
+// Getting changes of the subscription
+func (s *Service) History(ctx context.Context, id int) ([]models.SubscriptionEvent, error) {
+	if id <= 0 {
+		return nil, models.NewErrBadRequest(errors.New("Invalid id"))
+	}
+	events, err := s.Database.History(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// The user reaches the history of the subscription which last state belongs to the user
+	if user, ok := restrictedUser(ctx); ok {
+		if len(events) == 0 {
+			return nil, models.NewErrNotFound()
+		}
+		last := events[len(events)-1]
+		state := last.After
+		if state == nil {
+			state = last.Before
+		}
+		if state == nil || state.UserUUID != user {
+			return nil, models.NewErrNotFound()
+		}
+	}
+	return events, nil
+}
+
+// Scheduling the price change of the subscription
+func (s *Service) SchedulePrice(ctx context.Context, id int, change models.PriceChange) error {
+	// Validating the price change
+	if change.Price <= 0 {
+		return models.NewErrBadRequest(errors.New("Invalid price"))
+	}
+	if !change.EffectiveFrom.Valid {
+		return models.NewErrBadRequest(errors.New("Empty effective from"))
+	}
+
+	// The price can be changed only within the subscription's time bounds
+	subscription, err := s.Read(ctx, models.SubscriptionIdentifier{ID: id})
+	if err != nil {
+		return err
+	}
+	if !change.EffectiveFrom.Time.After(subscription.StartDate.Time) ||
+		(subscription.EndDate.Valid && change.EffectiveFrom.Time.After(subscription.EndDate.Time)) {
+		return models.NewErrBadRequest(errors.New("Effective from should be after start date and not after end date"))
+	}
+	return s.Database.SchedulePrice(ctx, id, change)
+}
+
+// Getting price history of the subscription starting with its own price
+func (s *Service) Prices(ctx context.Context, id int) ([]models.PriceChange, error) {
+	subscription, err := s.Read(ctx, models.SubscriptionIdentifier{ID: id})
+	if err != nil {
+		return nil, err
+	}
+	changes, err := s.Database.Prices(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return append([]models.PriceChange{{Price: subscription.Price, EffectiveFrom: subscription.StartDate, CreatedAt: subscription.CreatedAt}}, changes...), nil
+}
+
+// Executing the bulk operations. Invalid operations fail without reaching the database, in all-or-nothing mode they abort the other operations
+func (s *Service) Bulk(ctx context.Context, request models.BulkRequest) ([]models.BulkResult, error) {
+	// Validating the request
+	if request.Mode == "" {
+		request.Mode = models.BulkAllOrNothing
+	}
+	if request.Mode != models.BulkAllOrNothing && request.Mode != models.BulkBestEffort {
+		return nil, models.NewErrBadRequest(errors.New("Invalid mode " + request.Mode))
+	}
+	if len(request.Operations) == 0 || len(request.Operations) > models.BulkLimit {
+		return nil, models.NewErrBadRequest(fmt.Errorf("Amount of operations should be from 1 to %d", models.BulkLimit))
+	}
+	allOrNothing := request.Mode == models.BulkAllOrNothing
+
+	// Validating the operations, the valid ones are being executed
+	results := make([]models.BulkResult, len(request.Operations))
+	operations, indexes := []models.BulkOperation{}, []int{}
+	invalid := false
+	for i, operation := range request.Operations {
+		results[i] = models.BulkResult{Index: i, Op: operation.Op}
+		switch operation.Op {
+		case models.BulkCreate, models.BulkUpdate:
+			if err := s.resolveService(ctx, &operation.Subscription); err != nil {
+				return nil, err
+			}
+			setDefaults(&operation.Subscription, s.ExchangeRates.Base())
+			if results[i].Err = ownSubscription(ctx, &operation.Subscription); results[i].Err == nil {
+				results[i].Err = s.ValidateSubscription(operation.Subscription)
+			}
+			if results[i].Err == nil && operation.Op == models.BulkUpdate && operation.Subscription.ID <= 0 {
+				results[i].Err = models.NewErrBadRequest(errors.New("Invalid id"))
+			} else if results[i].Err == nil && operation.Op == models.BulkUpdate {
+				results[i].Err = s.checkOwner(ctx, operation.Subscription.ID)
+			}
+		case models.BulkDelete:
+			if operation.ID <= 0 {
+				results[i].Err = models.NewErrBadRequest(errors.New("Invalid id"))
+			} else {
+				results[i].Err = s.checkOwner(ctx, operation.ID)
+			}
+		default:
+			results[i].Err = models.NewErrBadRequest(errors.New("Unknown operation " + operation.Op))
+		}
+		if results[i].Err != nil {
+			invalid = true
+			continue
+		}
+		operations, indexes = append(operations, operation), append(indexes, i)
+	}
+	if invalid && allOrNothing {
+		for i := range results {
+			if results[i].Err == nil {
+				results[i].Err = models.NewErrAborted()
+			}
+		}
+		return results, nil
+	}
+
+	// Executing the operations
+	executed, err := s.Database.Bulk(ctx, operations, allOrNothing)
+	if err != nil {
+		return nil, err
+	}
+	for i, result := range executed {
+		result.Index = indexes[i]
+		results[indexes[i]] = result
+		if s.Cache != nil && result.Op != models.BulkCreate {
+			s.Cache.DeleteSubscription(ctx, models.SubscriptionIdentifier{ID: result.ID})
+		}
+	}
+	return results, nil
+}
+
+// Exporting the subscriptions filtered the same way as the list without the limit
+func (s *Service) Export(ctx context.Context, params models.SubscriptionsWithinPeriod, fn func(models.Subscription) error) error {
+	if err := validateFilters(params); err != nil {
+		return err
+	}
+	if err := restrictFilters(ctx, &params); err != nil {
+		return err
 	}
-	// Getting list of subscriptions from the database
+	if err := s.resolveFilters(ctx, &params); err != nil {
+		return err
+	}
+	return s.Database.Export(ctx, params, fn)
+}
+
+// Importing the subscriptions in one transaction. Nothing is imported if any of the rows is invalid or conflicts, in dry-run mode the rows are only
+// being validated and checked for conflicts
+func (s *Service) Import(ctx context.Context, rows []models.ImportRow, dryRun bool) (models.ImportResult, error) {
+	result := models.ImportResult{DryRun: dryRun, Rows: len(rows), Errors: []models.ImportError{}}
+	if len(rows) == 0 {
+		return result, models.NewErrBadRequest(errors.New("Empty file"))
+	}
+
+	// Validating the rows
+	operations := make([]models.BulkOperation, 0, len(rows))
+	for _, row := range rows {
+		if row.Err == nil {
+			if err := s.resolveService(ctx, &row.Subscription); err != nil {
+				return result, err
+			}
+			setDefaults(&row.Subscription, s.ExchangeRates.Base())
+			if row.Err = ownSubscription(ctx, &row.Subscription); row.Err == nil {
+				row.Err = s.ValidateSubscription(row.Subscription)
+			}
+		}
+		if row.Err != nil {
+			result.Errors = append(result.Errors, models.ImportError{Line: row.Line, Error: row.Err.Error()})
+			continue
+		}
+		operations = append(operations, models.BulkOperation{Op: models.BulkCreate, Subscription: row.Subscription})
+	}
+	if len(result.Errors) > 0 {
+		return result, models.NewErrBadRequest(errors.New("Invalid rows"))
+	}
+
+	// Checking the conflicts without importing
+	if dryRun {
+		lines := make(map[string]int)
+		for i, operation := range operations {
+			key := operation.Subscription.UserUUID.String() + ":" + operation.Subscription.ServiceName
+			_, err := s.Database.Read(ctx, models.SubscriptionIdentifier{UserUUID: operation.Subscription.UserUUID, ServiceName: operation.Subscription.ServiceName})
+			if err != nil && !errors.Is(err, models.ErrNotFound) {
+				return result, err
+			}
+			if _, ok := lines[key]; ok || err == nil {
+				result.Errors = append(result.Errors, models.ImportError{Line: rows[i].Line, Error: models.NewErrConflict().Error()})
+			}
+			lines[key] = rows[i].Line
+		}
+		if len(result.Errors) > 0 {
+			return result, models.NewErrConflict()
+		}
+		return result, nil
+	}
+
+	// Importing the subscriptions
+	results, err := s.Database.Bulk(ctx, operations, true)
+	if err != nil {
+		return result, err
+	}
+	for i, res := range results {
+		if res.Err != nil && !errors.Is(res.Err, models.ErrAborted) {
+			result.Errors = append(result.Errors, models.ImportError{Line: rows[i].Line, Error: res.Err.Error()})
+			return result, res.Err
+		}
+	}
+
+	// Dropping the stale cache entries under the keys of the imported subscriptions
+	if s.Cache != nil {
+		for i, operation := range operations {
+			s.Cache.DeleteSubscription(ctx, models.SubscriptionIdentifier{ID: results[i].ID, UserUUID: operation.Subscription.UserUUID, ServiceName: operation.Subscription.ServiceName})
+		}
+	}
+	result.Imported = len(operations)
+	return result, nil
+}
+
+// Gettng list of subscrtiption. The cursors to the next and the previous pages are returned if there are such pages, total amount of the subscriptions
+// is returned unless the count is skipped
+func (s *Service) List(ctx context.Context, params models.SubscriptionsWithinPeriod) (models.ListResponse, error) {
+	if err := validateFilters(params); err != nil {
+		return models.ListResponse{}, err
+	}
+	if err := restrictFilters(ctx, &params); err != nil {
+		return models.ListResponse{}, err
+	}
+	if err := s.resolveFilters(ctx, &params); err != nil {
+		return models.ListResponse{}, err
+	}
+	// Validating paging
+	if params.Limit < 0 || params.Offset < 0 {
+		return models.ListResponse{}, models.NewErrBadRequest(errors.New("Negative limit or offset"))
+	}
+	if params.Cursor != nil && params.Offset > 0 {
+		return models.ListResponse{}, models.NewErrBadRequest(errors.New("Cursor and offset can't be combined"))
+	}
+	// The cursor should be got from the list sorted the same way
+	order := params.Order()
+	if params.Cursor != nil {
+		if _, err := params.Cursor.Subscription(order); err != nil {
+			return models.ListResponse{}, err
+		}
+	}
+
+	// Getting list of subscriptions from the database, one more subscription is being read to know if there is the next page
+	limit := params.Limit
+	params.Limit++
 	res, err := s.Database.List(ctx, params)
-	return res, err
+	if err != nil {
+		return models.ListResponse{}, err
+	}
+	backward := params.Cursor != nil && params.Cursor.Backward
+	more := len(res) > limit
+	if more && backward {
+		res = res[1:]
+	} else if more {
+		res = res[:limit]
+	}
+
+	// Counting all the subscriptions
+	list := models.ListResponse{Subscriptions: res, Limit: limit, Offset: params.Offset}
+	if !params.SkipCount {
+		total, err := s.Database.Count(ctx, params)
+		if err != nil {
+			return models.ListResponse{}, err
+		}
+		list.Total = &total
+	}
+
+	// Getting the cursors to the pages around
+	if len(res) == 0 {
+		return list, nil
+	}
+	first, last := res[0], res[len(res)-1]
+	if (backward && more) || (!backward && (params.Cursor != nil || params.Offset > 0)) {
+		list.PrevCursor = models.NewCursor(first, order, true).Encode()
+	}
+	if backward || more {
+		list.NextCursor = models.NewCursor(last, order, false).Encode()
+	}
+	return list, nil
 }
 
 // Getting summary of subscriptions
 func (s *Service) Summary(ctx context.Context, params models.SubscriptionsWithinPeriod) (models.SummaryResponse, error) {
 	// Validating time bounds
+	if !params.StartDate.Valid || !params.EndDate.Valid {
+		return models.SummaryResponse{}, models.NewErrBadRequest(errors.New("Start date and end date should be provided"))
+	}
 	if params.EndDate.Valid && params.StartDate.Valid && params.EndDate.Time.Before(params.StartDate.Time) {
 		return models.SummaryResponse{}, models.NewErrBadRequest(errors.New("Invalid time bound"))
 	}
+	// Validating currency
+	params.Currency = strings.ToUpper(params.Currency)
+	if params.Currency != "" {
+		if err := rates.ValidateCurrency(params.Currency); err != nil {
+			return models.SummaryResponse{}, err
+		}
+	}
+
+	// Validating grouping
+	for i, field := range params.GroupBy {
+		switch field {
+		case models.GroupByServiceName, models.GroupByUserUUID, models.GroupByMonth, models.GroupByCategory, models.GroupByTag:
+		default:
+			return models.SummaryResponse{}, models.NewErrBadRequest(errors.New("Invalid group by " + field))
+		}
+		if slices.Contains(params.GroupBy[:i], field) {
+			return models.SummaryResponse{}, models.NewErrBadRequest(errors.New("Duplicate group by " + field))
+		}
+	}
+
 	// Getting info from the database
+	if err := restrictFilters(ctx, &params); err != nil {
+		return models.SummaryResponse{}, err
+	}
+	if err := s.resolveFilters(ctx, &params); err != nil {
+		return models.SummaryResponse{}, err
+	}
 	res, err := s.Database.Summary(ctx, params)
-	return res, err
+	if err != nil {
+		return res, err
+	}
+
+	// The total is being calculated in the requested currency. If the currency isn't provided, the only currency of the subscriptions or the base currency is used
+	res.Currency = params.Currency
+	if res.Currency == "" && len(res.Totals) == 1 {
+		res.Currency = res.Totals[0].Currency
+	} else if res.Currency == "" {
+		res.Currency = s.ExchangeRates.Base()
+	}
+
+	// Converting totals into the currency
+	used := make(map[string]float64)
+	res.Total, err = s.convert(ctx, res.Totals, res.Currency, used)
+	if err != nil {
+		return models.SummaryResponse{}, err
+	}
+	for i := range res.Groups {
+		res.Groups[i].Currency = res.Currency
+		res.Groups[i].Total, err = s.convert(ctx, res.Groups[i].Totals, res.Currency, used)
+		if err != nil {
+			return models.SummaryResponse{}, err
+		}
+	}
+	if len(used) > 0 {
+		res.Rates = used
+	}
+	return res, nil
+}
+
+// Getting time series of subscriptions
+func (s *Service) Timeseries(ctx context.Context, params models.SubscriptionsWithinPeriod) (models.TimeseriesResponse, error) {
+	// Validating time bounds
+	if !params.StartDate.Valid || !params.EndDate.Valid {
+		return models.TimeseriesResponse{}, models.NewErrBadRequest(errors.New("Start date and end date should be provided"))
+	}
+	if params.EndDate.Time.Before(params.StartDate.Time) {
+		return models.TimeseriesResponse{}, models.NewErrBadRequest(errors.New("Invalid time bound"))
+	}
+	// Validating bucket, the series is monthly by default
+	switch params.Bucket {
+	case "":
+		params.Bucket = models.BucketMonth
+	case models.BucketWeek, models.BucketMonth, models.BucketQuarter, models.BucketYear:
+	default:
+		return models.TimeseriesResponse{}, models.NewErrBadRequest(errors.New("Invalid bucket " + params.Bucket))
+	}
+	// Validating currency, the base currency is used by default
+	params.Currency = strings.ToUpper(params.Currency)
+	if params.Currency == "" {
+		params.Currency = s.ExchangeRates.Base()
+	}
+	if err := rates.ValidateCurrency(params.Currency); err != nil {
+		return models.TimeseriesResponse{}, err
+	}
+
+	// Getting buckets from the database
+	if err := restrictFilters(ctx, &params); err != nil {
+		return models.TimeseriesResponse{}, err
+	}
+	if err := s.resolveFilters(ctx, &params); err != nil {
+		return models.TimeseriesResponse{}, err
+	}
+	buckets, err := s.Database.Timeseries(ctx, params)
+	if err != nil {
+		return models.TimeseriesResponse{}, err
+	}
+
+	// Converting spend into the currency
+	res := models.TimeseriesResponse{Bucket: params.Bucket, Currency: params.Currency, Buckets: buckets}
+	used := make(map[string]float64)
+	for i := range res.Buckets {
+		res.Buckets[i].Currency = res.Currency
+		res.Buckets[i].Spend, err = s.convert(ctx, res.Buckets[i].Totals, res.Currency, used)
+		if err != nil {
+			return models.TimeseriesResponse{}, err
+		}
+	}
+	if len(used) > 0 {
+		res.Rates = used
+	}
+	return res, nil
+}
+
+// Converting totals in different currencies into the currency by the rates of the tenant. The rates used for conversion are being added to the used rates
+func (s *Service) convert(ctx context.Context, totals []models.CurrencyTotal, currency string, used map[string]float64) (float64, error) {
+	var sum float64
+	for _, total := range totals {
+		rate, err := s.ExchangeRates.Rate(ctx, total.Currency, currency)
+		if err != nil {
+			return 0, err
+		}
+		if total.Currency != currency {
+			used[total.Currency] = rate
+		}
+		sum += float64(total.Total) * rate
+	}
+	return math.Round(sum*100) / 100, nil
+}
+
+// Getting exchange rates of the tenant
+func (s *Service) Rates(ctx context.Context) (models.ExchangeRates, error) {
+	return s.ExchangeRates.Get(ctx), nil
+}
+
+// Replacing exchange rates of the tenant
+func (s *Service) SetRates(ctx context.Context, exchangeRates models.ExchangeRates) error {
+	exchangeRates.Base = strings.ToUpper(exchangeRates.Base)
+	return s.ExchangeRates.Set(ctx, exchangeRates)
 }
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS billing_unit TEXT NOT NULL DEFAULT 'month' CHECK (billing_unit IN ('week', 'month', 'quarter', 'year')),
+    ADD COLUMN IF NOT EXISTS billing_interval INTEGER NOT NULL DEFAULT 1 CHECK (billing_interval > 0);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE subscriptions
+    DROP COLUMN IF EXISTS billing_unit,
+    DROP COLUMN IF EXISTS billing_interval;
+-- +goose StatementEnd
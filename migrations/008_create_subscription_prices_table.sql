@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS subscription_prices(
+    id SERIAL PRIMARY KEY,
+    subscription_id INTEGER NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    price INTEGER NOT NULL CHECK (price > 0),
+    effective_from TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT unique_effective_from UNIQUE (subscription_id, effective_from)
+);
+ALTER TABLE subscription_events
+    ADD COLUMN IF NOT EXISTS price JSONB,
+    DROP CONSTRAINT IF EXISTS subscription_events_action_check,
+    ADD CONSTRAINT subscription_events_action_check CHECK (action IN ('create', 'update', 'delete', 'restore', 'purge', 'schedule_price'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM subscription_events WHERE action = 'schedule_price';
+ALTER TABLE subscription_events
+    DROP CONSTRAINT IF EXISTS subscription_events_action_check,
+    ADD CONSTRAINT subscription_events_action_check CHECK (action IN ('create', 'update', 'delete', 'restore', 'purge')),
+    DROP COLUMN IF EXISTS price;
+DROP TABLE IF EXISTS subscription_prices;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose NO TRANSACTION
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_subscriptions_service_name_pattern ON subscriptions(service_name text_pattern_ops);
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_subscriptions_service_name_lower ON subscriptions(lower(service_name) text_pattern_ops);
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_subscriptions_service_name_trgm ON subscriptions USING GIN (service_name gin_trgm_ops);
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_subscriptions_price ON subscriptions(price);
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_subscriptions_dates ON subscriptions(start_date, end_date);
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_subscriptions_created_at ON subscriptions(created_at);
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_subscriptions_updated_at ON subscriptions(updated_at);
+
+-- +goose Down
+DROP INDEX CONCURRENTLY IF EXISTS idx_subscriptions_service_name_pattern;
+DROP INDEX CONCURRENTLY IF EXISTS idx_subscriptions_service_name_lower;
+DROP INDEX CONCURRENTLY IF EXISTS idx_subscriptions_service_name_trgm;
+DROP INDEX CONCURRENTLY IF EXISTS idx_subscriptions_price;
+DROP INDEX CONCURRENTLY IF EXISTS idx_subscriptions_dates;
+DROP INDEX CONCURRENTLY IF EXISTS idx_subscriptions_created_at;
+DROP INDEX CONCURRENTLY IF EXISTS idx_subscriptions_updated_at;
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS services(
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    aliases TEXT[] NOT NULL DEFAULT '{}',
+    category TEXT NOT NULL DEFAULT '',
+    default_price INTEGER NOT NULL DEFAULT 0 CHECK (default_price >= 0),
+    currency CHAR(3) NOT NULL DEFAULT 'RUB' CHECK (currency ~ '^[A-Z]{3}$'),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE IF NOT EXISTS service_names(
+    name TEXT PRIMARY KEY,
+    service_id INTEGER NOT NULL REFERENCES services(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_service_names_service_id ON service_names(service_id);
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS service_id INTEGER REFERENCES services(id);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_service_id ON subscriptions(service_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_subscriptions_service_id;
+ALTER TABLE subscriptions
+    DROP COLUMN IF EXISTS service_id;
+DROP TABLE IF EXISTS service_names;
+DROP TABLE IF EXISTS services;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS category TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_subscriptions_category ON subscriptions(category);
+CREATE TABLE IF NOT EXISTS tags(
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE
+);
+CREATE TABLE IF NOT EXISTS subscription_tags(
+    subscription_id INTEGER NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (subscription_id, tag_id)
+);
+CREATE INDEX IF NOT EXISTS idx_subscription_tags_tag_id ON subscription_tags(tag_id, subscription_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS subscription_tags;
+DROP TABLE IF EXISTS tags;
+DROP INDEX IF EXISTS idx_subscriptions_category;
+ALTER TABLE subscriptions
+    DROP COLUMN IF EXISTS category;
+-- +goose StatementEnd
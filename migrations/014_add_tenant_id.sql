@@ -0,0 +1,55 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_subscriptions_tenant_id_user_uuid_service_name ON subscriptions(tenant_id, user_uuid, service_name)
+    WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_subscriptions_tenant_id_id ON subscriptions(tenant_id, id);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_tenant_id_service_name ON subscriptions(tenant_id, service_name);
+ALTER TABLE subscription_events
+    ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+DROP INDEX IF EXISTS idx_subscription_events_subscription_id;
+CREATE INDEX IF NOT EXISTS idx_subscription_events_tenant_id_subscription_id ON subscription_events(tenant_id, subscription_id, id);
+ALTER TABLE services
+    ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+CREATE INDEX IF NOT EXISTS idx_services_tenant_id_name ON services(tenant_id, name);
+ALTER TABLE service_names
+    ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default',
+    DROP CONSTRAINT IF EXISTS service_names_pkey,
+    ADD PRIMARY KEY (tenant_id, name);
+ALTER TABLE api_keys
+    ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE idempotency_keys
+    ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default',
+    ADD COLUMN IF NOT EXISTS subject TEXT NOT NULL DEFAULT '',
+    DROP CONSTRAINT IF EXISTS idempotency_keys_pkey,
+    ADD PRIMARY KEY (tenant_id, subject, key);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM idempotency_keys;
+ALTER TABLE idempotency_keys
+    DROP CONSTRAINT IF EXISTS idempotency_keys_pkey,
+    DROP COLUMN IF EXISTS subject,
+    DROP COLUMN IF EXISTS tenant_id,
+    ADD PRIMARY KEY (key);
+ALTER TABLE api_keys
+    DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE service_names
+    DROP CONSTRAINT IF EXISTS service_names_pkey,
+    DROP COLUMN IF EXISTS tenant_id,
+    ADD PRIMARY KEY (name);
+DROP INDEX IF EXISTS idx_services_tenant_id_name;
+ALTER TABLE services
+    DROP COLUMN IF EXISTS tenant_id;
+DROP INDEX IF EXISTS idx_subscription_events_tenant_id_subscription_id;
+CREATE INDEX IF NOT EXISTS idx_subscription_events_subscription_id ON subscription_events(subscription_id, id);
+ALTER TABLE subscription_events
+    DROP COLUMN IF EXISTS tenant_id;
+DROP INDEX IF EXISTS idx_subscriptions_tenant_id_service_name;
+DROP INDEX IF EXISTS idx_subscriptions_tenant_id_id;
+DROP INDEX IF EXISTS idx_subscriptions_tenant_id_user_uuid_service_name;
+ALTER TABLE subscriptions
+    DROP COLUMN IF EXISTS tenant_id;
+-- +goose StatementEnd